@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -24,6 +29,8 @@ type App struct {
 	ctx            context.Context
 	store          *storage.Store
 	sessionManager *ssh.SessionManager
+	prompts        map[string]chan interface{} // 等待前端响应的提示
+	promptsMu      sync.Mutex
 }
 
 // NewApp 创建新的 App 应用
@@ -31,12 +38,81 @@ func NewApp() *App {
 	return &App{
 		store:          storage.NewStore(),
 		sessionManager: ssh.GetSessionManager(),
+		prompts:        make(map[string]chan interface{}),
 	}
 }
 
 // startup 应用启动时调用
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	ssh.GetHostKeyManager().SetPrompter(a.promptHostKey)
+	ssh.SetConfigResolver(a.store.GetConfig)
+	ssh.SetKeyResolver(a.store.VaultKeyMaterial)
+	ssh.SetTunnelEventHandler(a.emitTunnelEvent)
+	ssh.SetSessionStateHandler(a.emitSessionState)
+	ssh.SetPassphrasePrompter(a.promptPassphrase)
+	ssh.SetKeyboardInteractivePrompter(a.promptKeyboardInteractive)
+
+	settings, err := a.store.LoadSettings()
+	if err != nil {
+		fmt.Printf("startup: 读取设置失败: %v\n", err)
+	}
+	ssh.GetHostKeyManager().SetUseSystemKnownHosts(settings.UseSystemKnownHosts)
+}
+
+// ============ 前端交互提示 ============
+
+// promptTimeout 等待用户响应的最长时间
+const promptTimeout = 2 * time.Minute
+
+// requestPrompt 向前端发送提示事件并等待响应，超时返回错误
+func (a *App) requestPrompt(eventName string, payload map[string]interface{}) (interface{}, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	requestID := hex.EncodeToString(idBytes)
+
+	ch := make(chan interface{}, 1)
+	a.promptsMu.Lock()
+	a.prompts[requestID] = ch
+	a.promptsMu.Unlock()
+
+	defer func() {
+		a.promptsMu.Lock()
+		delete(a.prompts, requestID)
+		a.promptsMu.Unlock()
+	}()
+
+	payload["requestId"] = requestID
+	runtime.EventsEmit(a.ctx, eventName, payload)
+
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-time.After(promptTimeout):
+		runtime.EventsEmit(a.ctx, eventName+"-timeout", map[string]interface{}{
+			"requestId": requestID,
+		})
+		return nil, fmt.Errorf("等待用户响应超时")
+	}
+}
+
+// resolvePrompt 将前端的响应交给等待中的提示
+func (a *App) resolvePrompt(requestID string, response interface{}) error {
+	a.promptsMu.Lock()
+	ch, exists := a.prompts[requestID]
+	a.promptsMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("提示请求不存在或已超时")
+	}
+
+	select {
+	case ch <- response:
+	default:
+	}
+	return nil
 }
 
 // ============ 密钥管理 ============
@@ -74,6 +150,9 @@ func (a *App) VerifyEncryptionKey(key string) error {
 	testStore := storage.NewStore()
 	if err := testStore.SetUserKey(key); err != nil {
 		fmt.Printf("VerifyEncryptionKey: 设置测试密钥失败: %v\n", err)
+		if errors.Is(err, storage.ErrInvalidKey) {
+			return fmt.Errorf("密钥验证失败: %w", err)
+		}
 		return err
 	}
 
@@ -100,6 +179,19 @@ func (a *App) VerifyEncryptionKey(key string) error {
 	return nil
 }
 
+// ChangeEncryptionKey 更换加密密钥，所有配置使用新密钥重新加密
+func (a *App) ChangeEncryptionKey(oldKey, newKey string) error {
+	fmt.Printf("ChangeEncryptionKey: 开始更换密钥\n")
+
+	if err := a.store.ChangeUserKey(oldKey, newKey); err != nil {
+		fmt.Printf("ChangeEncryptionKey: 更换密钥失败: %v\n", err)
+		return err
+	}
+
+	fmt.Printf("ChangeEncryptionKey: 密钥更换成功\n")
+	return nil
+}
+
 // IsKeySet 检查是否已设置密钥
 func (a *App) IsKeySet() bool {
 	return a.store.IsKeySet()
@@ -213,6 +305,12 @@ func (a *App) SaveConfig(config models.SSHConfig) error {
 	}
 	config.UpdatedAt = time.Now()
 
+	for _, jump := range config.JumpHosts {
+		if jump.ConfigID != "" && jump.ConfigID == config.ID {
+			return fmt.Errorf("跳板机不能引用配置自身")
+		}
+	}
+
 	// 检查是否是更新
 	if existingConfig, err := a.store.GetConfig(config.ID); err == nil {
 		// 是更新操作
@@ -237,6 +335,41 @@ func (a *App) DeleteConfig(id string) error {
 	return a.store.DeleteConfig(id)
 }
 
+// PreviewSSHConfigImport 预览从 OpenSSH 配置文件导入的结果，路径为空时使用 ~/.ssh/config
+func (a *App) PreviewSSHConfigImport(configPath string) ([]models.ImportPreviewItem, error) {
+	return a.store.PreviewSSHConfigImport(configPath)
+}
+
+// ImportSSHConfig 从 OpenSSH 配置文件导入选中的主机，aliases 为空时导入全部
+func (a *App) ImportSSHConfig(configPath string, aliases []string) (*models.ImportResult, error) {
+	return a.store.ImportSSHConfig(configPath, aliases)
+}
+
+// ExportSSHConfig 将选中的配置导出为 OpenSSH config 片段（不含密码），ids 为空时导出全部
+func (a *App) ExportSSHConfig(configIDs []string) (string, error) {
+	return a.store.ExportSSHConfigSnippet(configIDs)
+}
+
+// ExportBundle 将选中的配置导出为使用导出密码加密的文件
+func (a *App) ExportBundle(configIDs []string, filePath, passphrase string) error {
+	return a.store.ExportBundle(configIDs, filePath, passphrase)
+}
+
+// ImportBundle 导入导出文件，strategy 为 skip、overwrite 或 keep
+func (a *App) ImportBundle(filePath, passphrase, strategy string) (*models.ImportResult, error) {
+	return a.store.ImportBundle(filePath, passphrase, strategy)
+}
+
+// ListConfigBackups 列出配置文件的自动备份
+func (a *App) ListConfigBackups() ([]models.BackupInfo, error) {
+	return a.store.ListBackups()
+}
+
+// RestoreConfigBackup 恢复指定备份（需能用当前密钥解密）
+func (a *App) RestoreConfigBackup(name string) error {
+	return a.store.RestoreBackup(name)
+}
+
 // ============ 会话管理 ============
 
 // CreateSession 创建 SSH 会话
@@ -250,6 +383,13 @@ func (a *App) CreateSession(configID string) error {
 	return err
 }
 
+// emitSessionState 推送会话连接状态（连接、重连中、重连失败）
+func (a *App) emitSessionState(state models.SessionState) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "session-state", state)
+	}
+}
+
 // CloseSession 关闭 SSH 会话
 func (a *App) CloseSession(configID string) error {
 	return a.sessionManager.CloseSession(configID)
@@ -274,6 +414,40 @@ func (a *App) GetSessionUptime(configID string) (string, error) {
 	return duration.String(), nil
 }
 
+// ============ 端口转发 ============
+
+// emitTunnelEvent 推送端口转发状态和流量统计
+func (a *App) emitTunnelEvent(info models.TunnelInfo) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, "tunnel-status", info)
+	}
+}
+
+// StartLocalForward 启动本地端口转发（ssh -L）
+func (a *App) StartLocalForward(configID, listenAddr, targetAddr string) (*models.TunnelInfo, error) {
+	return a.sessionManager.StartLocalForward(configID, listenAddr, targetAddr)
+}
+
+// StartRemoteForward 启动远程端口转发（ssh -R）
+func (a *App) StartRemoteForward(configID, listenAddr, targetAddr string) (*models.TunnelInfo, error) {
+	return a.sessionManager.StartRemoteForward(configID, listenAddr, targetAddr)
+}
+
+// StartSocksProxy 启动通过会话转发的 SOCKS5 代理（ssh -D），username 为空时不需要认证
+func (a *App) StartSocksProxy(configID, listenAddr, username, password string) (*models.TunnelInfo, error) {
+	return a.sessionManager.StartDynamicForward(configID, listenAddr, username, password)
+}
+
+// StopTunnel 停止端口转发
+func (a *App) StopTunnel(tunnelID string) error {
+	return a.sessionManager.StopTunnel(tunnelID)
+}
+
+// ListTunnels 列出端口转发，configID 为空时列出全部
+func (a *App) ListTunnels(configID string) []models.TunnelInfo {
+	return a.sessionManager.ListTunnels(configID)
+}
+
 // ============ 终端管理 ============
 
 // OpenTerminal 在系统终端中打开 SSH 连接
@@ -292,6 +466,22 @@ func (a *App) OpenTerminal(configID string) error {
 			config.Username, config.Host, config.Port)
 	}
 
+	if config.AgentForwarding {
+		sshCmd += " -A"
+	}
+	if config.X11Forwarding {
+		sshCmd += " -X"
+	}
+
+	// 跳板机链使用 ProxyJump
+	if len(config.JumpHosts) > 0 {
+		jumpSpec, err := ssh.JumpHostSpec(config)
+		if err != nil {
+			return err
+		}
+		sshCmd += " -J " + jumpSpec
+	}
+
 	// macOS: 使用 AppleScript 打开 Terminal.app
 	cmd := exec.Command("osascript", "-e",
 		fmt.Sprintf(`tell application "Terminal" to do script "%s"`, sshCmd))
@@ -306,7 +496,7 @@ func (a *App) ExecuteCommand(configID, command string) (string, error) {
 		return "", err
 	}
 
-	return ssh.ExecuteCommand(session.SSHClient, command)
+	return ssh.ExecuteCommand(session.Client(), command)
 }
 
 // ExecuteSSHCommand 执行SSH命令并返回详细信息（用于Web终端）
@@ -317,7 +507,7 @@ func (a *App) ExecuteSSHCommand(configID, command string) (*models.CommandResult
 	}
 
 	// 执行命令
-	output, err := ssh.ExecuteCommand(session.SSHClient, command)
+	output, err := ssh.ExecuteCommand(session.Client(), command)
 	if err != nil {
 		return &models.CommandResult{
 			Success: false,
@@ -327,14 +517,14 @@ func (a *App) ExecuteSSHCommand(configID, command string) (*models.CommandResult
 	}
 
 	// 获取当前路径
-	currentPath, _ := ssh.ExecuteCommand(session.SSHClient, "pwd")
+	currentPath, _ := ssh.ExecuteCommand(session.Client(), "pwd")
 	currentPath = strings.TrimSpace(currentPath)
 
 	// 获取用户名和主机名
-	username, _ := ssh.ExecuteCommand(session.SSHClient, "whoami")
+	username, _ := ssh.ExecuteCommand(session.Client(), "whoami")
 	username = strings.TrimSpace(username)
 
-	hostname, _ := ssh.ExecuteCommand(session.SSHClient, "hostname")
+	hostname, _ := ssh.ExecuteCommand(session.Client(), "hostname")
 	hostname = strings.TrimSpace(hostname)
 
 	return &models.CommandResult{
@@ -364,13 +554,13 @@ func (a *App) ConnectSSH(configID string) (*models.ConnectionResult, error) {
 	}
 
 	// 获取连接信息
-	currentPath, _ := ssh.ExecuteCommand(session.SSHClient, "pwd")
+	currentPath, _ := ssh.ExecuteCommand(session.Client(), "pwd")
 	currentPath = strings.TrimSpace(currentPath)
 
-	username, _ := ssh.ExecuteCommand(session.SSHClient, "whoami")
+	username, _ := ssh.ExecuteCommand(session.Client(), "whoami")
 	username = strings.TrimSpace(username)
 
-	hostname, _ := ssh.ExecuteCommand(session.SSHClient, "hostname")
+	hostname, _ := ssh.ExecuteCommand(session.Client(), "hostname")
 	hostname = strings.TrimSpace(hostname)
 
 	// 获取欢迎信息
@@ -409,7 +599,7 @@ func (a *App) GetRemoteFiles(configID, path string) (*models.FileListResult, err
 	}
 
 	// 执行ls -la命令获取详细信息
-	output, err := ssh.ExecuteCommand(session.SSHClient, fmt.Sprintf("ls -la %s", path))
+	output, err := ssh.ExecuteCommand(session.Client(), fmt.Sprintf("ls -la %s", path))
 	if err != nil {
 		return &models.FileListResult{
 			Success: false,
@@ -481,7 +671,7 @@ func (a *App) ExecuteSudoCommand(configID, command string) (string, error) {
 		return "", err
 	}
 
-	return ssh.ExecuteSudoCommand(session.SSHClient, config.Password, command)
+	return ssh.ExecuteSudoCommand(session.Client(), config.Password, command)
 }
 
 // ============ 文件操作 ============
@@ -511,7 +701,7 @@ func (a *App) GetRemoteHome(configID string) (string, error) {
 		}
 	}
 
-	output, err := ssh.ExecuteCommand(session.SSHClient, "pwd")
+	output, err := ssh.ExecuteCommand(session.Client(), "pwd")
 	if err != nil {
 		return "", err
 	}
@@ -690,6 +880,456 @@ func (a *App) TestSSHConnection(configID string) error {
 	return session.Run("echo 'test'")
 }
 
+// ============ 主机密钥管理 ============
+
+// promptHostKey 首次连接未知主机时请求用户确认指纹
+func (a *App) promptHostKey(prompt ssh.HostKeyPrompt) bool {
+	response, err := a.requestPrompt("host-key-prompt", map[string]interface{}{
+		"host":        prompt.Host,
+		"keyType":     prompt.KeyType,
+		"fingerprint": prompt.Fingerprint,
+		"previous":    prompt.Previous,
+	})
+	if err != nil {
+		fmt.Printf("promptHostKey: %v\n", err)
+		return false
+	}
+
+	accepted, _ := response.(bool)
+	return accepted
+}
+
+// AnswerHostKeyPrompt 响应主机密钥确认请求
+func (a *App) AnswerHostKeyPrompt(requestID string, accept bool) error {
+	return a.resolvePrompt(requestID, accept)
+}
+
+// SetUseSystemKnownHosts 设置是否同时信任 ~/.ssh/known_hosts，设置会被保存
+func (a *App) SetUseSystemKnownHosts(enabled bool) error {
+	if _, err := a.store.UpdateSettings(func(settings *models.AppSettings) {
+		settings.UseSystemKnownHosts = enabled
+	}); err != nil {
+		return err
+	}
+	ssh.GetHostKeyManager().SetUseSystemKnownHosts(enabled)
+	return nil
+}
+
+// GetAppSettings 获取后端保存的应用设置
+func (a *App) GetAppSettings() (models.AppSettings, error) {
+	return a.store.LoadSettings()
+}
+
+// ListHostKeys 列出配置对应主机已信任的密钥
+func (a *App) ListHostKeys(configID string) ([]models.HostKeyEntry, error) {
+	config, err := a.store.GetConfig(configID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.GetHostKeyManager().ListHostKeys(config.Host, config.Port)
+}
+
+// RemoveHostKey 删除配置对应主机已信任的密钥，下次连接时重新确认
+func (a *App) RemoveHostKey(configID string) error {
+	config, err := a.store.GetConfig(configID)
+	if err != nil {
+		return err
+	}
+
+	removed, err := ssh.GetHostKeyManager().RemoveHostKeys(config.Host, config.Port)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("RemoveHostKey: 已删除 %s 的 %d 条主机密钥\n", config.Host, removed)
+	return nil
+}
+
+// RepinHostKey 重新获取主机当前密钥，用户核对新旧指纹并确认后替换已保存的记录
+func (a *App) RepinHostKey(configID string) (*models.HostKeyEntry, error) {
+	config, err := a.store.GetConfig(configID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ssh.FetchHostKey(config)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := ssh.GetHostKeyManager()
+	entries, err := manager.ListHostKeys(config.Host, config.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := ssh.HostKeyPrompt{
+		Host:        net.JoinHostPort(config.Host, config.Port),
+		KeyType:     key.Type(),
+		Fingerprint: gossh.FingerprintSHA256(key),
+	}
+	for _, entry := range entries {
+		if entry.Source == "app" && entry.Marker == "" {
+			prompt.Previous = append(prompt.Previous, entry.KeyType+" "+entry.Fingerprint)
+		}
+	}
+
+	// 获取到的密钥可能来自中间人，必须由用户核对指纹后才能保存
+	if !a.promptHostKey(prompt) {
+		return nil, ssh.ErrHostKeyRejected
+	}
+
+	return manager.PinHostKey(config.Host, config.Port, key)
+}
+
+// AddHostCertAuthority 信任指定主机模式（如 *.example.com）下由该 CA 签发的主机证书
+func (a *App) AddHostCertAuthority(hostPattern, caPublicKey string) error {
+	if strings.TrimSpace(hostPattern) == "" {
+		return fmt.Errorf("主机模式不能为空")
+	}
+
+	caKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(caPublicKey))
+	if err != nil {
+		return fmt.Errorf("解析 CA 公钥失败: %w", err)
+	}
+
+	return ssh.GetHostKeyManager().AddCertAuthority(strings.TrimSpace(hostPattern), caKey)
+}
+
+// ============ 证书认证 ============
+
+// GetCertificateInfo 获取配置所用用户证书的主体和有效期
+func (a *App) GetCertificateInfo(configID string) (*models.CertificateInfo, error) {
+	config, err := a.store.GetConfig(configID)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.CertPath == "" {
+		return nil, fmt.Errorf("该配置未使用证书认证")
+	}
+
+	cert, err := ssh.LoadCertificate(config.CertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.CertificateInfo(cert), nil
+}
+
+// ============ 密钥库 ============
+
+// ImportVaultKey 将私钥文件导入加密的密钥库，加密私钥需提供密码
+func (a *App) ImportVaultKey(name, filePath, passphrase string) (*models.VaultKey, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+
+	privateKey, info, err := ssh.ReadPrivateKey(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = filepath.Base(filePath)
+	}
+	info.Name = name
+	return a.store.AddVaultKey(*info, privateKey)
+}
+
+// GenerateKeyPair 生成密钥对并写入文件和/或保存到密钥库
+func (a *App) GenerateKeyPair(options models.KeyGenOptions) (*models.GeneratedKey, error) {
+	if options.SavePath == "" && !options.SaveToVault {
+		return nil, fmt.Errorf("请选择保存位置")
+	}
+	if options.SavePath != "" {
+		for _, path := range []string{options.SavePath, options.SavePath + ".pub"} {
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("文件已存在: %s", path)
+			}
+		}
+	}
+
+	privateKey, info, err := ssh.GenerateKeyPair(options.Type, options.Bits, options.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.GeneratedKey{
+		Type:        info.Type,
+		Fingerprint: info.Fingerprint,
+		PublicKey:   info.PublicKey,
+	}
+
+	if options.SavePath != "" {
+		data, err := ssh.EncodePrivateKey(privateKey, options.Passphrase, options.Comment)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(options.SavePath, data, 0600); err != nil {
+			return nil, fmt.Errorf("写入私钥文件失败: %w", err)
+		}
+		if err := os.WriteFile(options.SavePath+".pub", []byte(info.PublicKey+"\n"), 0644); err != nil {
+			// 不留下只有私钥的半成品，否则再次生成时会提示文件已存在
+			os.Remove(options.SavePath + ".pub")
+			os.Remove(options.SavePath)
+			return nil, fmt.Errorf("写入公钥文件失败: %w", err)
+		}
+		result.PrivatePath = options.SavePath
+	}
+
+	if options.SaveToVault {
+		info.Name = options.Name
+		if info.Name == "" {
+			info.Name = options.Comment
+		}
+		vaultKey, err := a.store.AddVaultKey(*info, privateKey)
+		if err != nil {
+			return nil, err
+		}
+		result.VaultKeyID = vaultKey.ID
+	}
+
+	return result, nil
+}
+
+// ListVaultKeys 列出密钥库中的私钥及引用它们的配置
+func (a *App) ListVaultKeys() []models.VaultKey {
+	return a.store.ListVaultKeys()
+}
+
+// ExportVaultKey 将密钥库中的私钥导出为文件，passphrase 非空时加密导出
+func (a *App) ExportVaultKey(id, filePath, passphrase string) error {
+	info, err := a.store.GetVaultKey(id)
+	if err != nil {
+		return err
+	}
+
+	material, err := a.store.VaultKeyMaterial(id)
+	if err != nil {
+		return err
+	}
+
+	data, err := ssh.EncodePrivateKey(material, passphrase, info.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入密钥文件失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteVaultKey 删除密钥库中未被使用的私钥
+func (a *App) DeleteVaultKey(id string) error {
+	return a.store.DeleteVaultKey(id)
+}
+
+// ============ 公钥安装 ============
+
+// keyAuthConfig 生成只使用指定私钥认证的配置副本
+func keyAuthConfig(config *models.SSHConfig, keyID, keyPath, passphrase string) models.SSHConfig {
+	keyConfig := *config
+	keyConfig.AuthType = "key"
+	keyConfig.AuthMethods = nil
+	keyConfig.Password = ""
+	keyConfig.KeyID = keyID
+	keyConfig.KeyPath = keyPath
+	keyConfig.KeyPassphrase = passphrase
+	keyConfig.CertPath = ""
+	return keyConfig
+}
+
+// InstallPublicKey 将密钥库或本地私钥对应的公钥安装到会话主机，并验证能否用该密钥登录
+func (a *App) InstallPublicKey(configID, keyID, keyPath, passphrase string) (*models.KeyInstallResult, error) {
+	session, err := a.sessionManager.GetSession(configID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := a.store.GetConfig(configID)
+	if err != nil {
+		return nil, err
+	}
+
+	var publicKey string
+	switch {
+	case keyID != "":
+		vaultKey, err := a.store.GetVaultKey(keyID)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = vaultKey.PublicKey
+	case keyPath != "":
+		publicKey, err = ssh.PublicKeyFromFile(keyPath, passphrase, config.Name)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("请选择要安装的密钥")
+	}
+
+	pub, _, _, _, err := gossh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("公钥格式错误: %w", err)
+	}
+
+	added, err := session.InstallPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.KeyInstallResult{
+		Added:       added,
+		Fingerprint: gossh.FingerprintSHA256(pub),
+	}
+
+	// 使用新的连接验证密钥登录
+	keyConfig := keyAuthConfig(config, keyID, keyPath, passphrase)
+	client, err := ssh.CreateSSHClient(&keyConfig)
+	if err != nil {
+		result.VerifyError = err.Error()
+		return result, nil
+	}
+	client.Close()
+	result.Verified = true
+
+	return result, nil
+}
+
+// SwitchToKeyAuth 确认私钥可用且公钥已在会话主机的 authorized_keys 中启用后，将配置改为密钥认证，
+// clearPassword 为 true 时清除保存的密码
+func (a *App) SwitchToKeyAuth(configID, keyID, keyPath, passphrase string, clearPassword bool) error {
+	if keyID == "" && keyPath == "" {
+		return fmt.Errorf("请选择要使用的密钥")
+	}
+
+	config, err := a.store.GetConfig(configID)
+	if err != nil {
+		return err
+	}
+
+	pub, err := ssh.LoadAuthPublicKey(keyID, keyPath, passphrase, config.Name)
+	if err != nil {
+		return fmt.Errorf("读取私钥失败: %w", err)
+	}
+
+	session, err := a.sessionManager.GetSession(configID)
+	if err != nil {
+		return fmt.Errorf("需要连接到主机以确认公钥已安装: %w", err)
+	}
+	entries, err := session.ListAuthorizedKeys()
+	if err != nil {
+		return err
+	}
+	fingerprint := gossh.FingerprintSHA256(pub)
+	installed := false
+	for _, entry := range entries {
+		if entry.Fingerprint == fingerprint && !entry.Disabled {
+			installed = true
+			break
+		}
+	}
+	if !installed {
+		return fmt.Errorf("公钥 %s 未安装到主机的 authorized_keys 或已被禁用，请先安装", fingerprint)
+	}
+
+	keyConfig := keyAuthConfig(config, keyID, keyPath, passphrase)
+	if !clearPassword {
+		keyConfig.Password = config.Password
+	}
+	keyConfig.UpdatedAt = time.Now()
+
+	return a.store.UpdateConfig(keyConfig)
+}
+
+// ListAuthorizedKeys 列出会话主机 ~/.ssh/authorized_keys 中的公钥
+func (a *App) ListAuthorizedKeys(configID string) ([]models.AuthorizedKeyEntry, error) {
+	session, err := a.sessionManager.GetSession(configID)
+	if err != nil {
+		return nil, err
+	}
+	return session.ListAuthorizedKeys()
+}
+
+// AddAuthorizedKey 添加一行 authorized_keys（可包含 from=、command= 等选项），
+// 公钥已存在时更新其选项，返回是否修改了文件
+func (a *App) AddAuthorizedKey(configID, line string) (bool, error) {
+	session, err := a.sessionManager.GetSession(configID)
+	if err != nil {
+		return false, err
+	}
+	return session.AddAuthorizedKey(line)
+}
+
+// RemoveAuthorizedKey 删除指纹匹配的公钥
+func (a *App) RemoveAuthorizedKey(configID, fingerprint string) (int, error) {
+	session, err := a.sessionManager.GetSession(configID)
+	if err != nil {
+		return 0, err
+	}
+	return session.RemoveAuthorizedKey(fingerprint)
+}
+
+// SetAuthorizedKeyDisabled 禁用或重新启用指纹匹配的公钥
+func (a *App) SetAuthorizedKeyDisabled(configID, fingerprint string, disabled bool) error {
+	session, err := a.sessionManager.GetSession(configID)
+	if err != nil {
+		return err
+	}
+	return session.SetAuthorizedKeyDisabled(fingerprint, disabled)
+}
+
+// ============ 私钥密码 ============
+
+// promptPassphrase 私钥密码未保存时请求用户输入，仅用于本次运行
+func (a *App) promptPassphrase(prompt ssh.PassphrasePrompt) (string, error) {
+	response, err := a.requestPrompt("key-passphrase-prompt", map[string]interface{}{
+		"keyPath":    prompt.KeyPath,
+		"configName": prompt.ConfigName,
+		"retry":      prompt.Retry,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	passphrase, _ := response.(string)
+	return passphrase, nil
+}
+
+// AnswerPassphrasePrompt 响应私钥密码输入请求，密码为空表示取消
+func (a *App) AnswerPassphrasePrompt(requestID, passphrase string) error {
+	return a.resolvePrompt(requestID, passphrase)
+}
+
+// ============ 交互式认证 ============
+
+// promptKeyboardInteractive 将服务器的 keyboard-interactive 质询转给前端
+func (a *App) promptKeyboardInteractive(prompt ssh.KeyboardInteractivePrompt) ([]string, error) {
+	response, err := a.requestPrompt("keyboard-interactive-prompt", map[string]interface{}{
+		"configName":  prompt.ConfigName,
+		"host":        prompt.Host,
+		"name":        prompt.Name,
+		"instruction": prompt.Instruction,
+		"questions":   prompt.Questions,
+		"echos":       prompt.Echos,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	answers, _ := response.([]string)
+	return answers, nil
+}
+
+// AnswerKeyboardInteractive 响应 keyboard-interactive 质询，answers 为空表示取消
+func (a *App) AnswerKeyboardInteractive(requestID string, answers []string) error {
+	return a.resolvePrompt(requestID, answers)
+}
+
 // ============ 文件选择对话框 ============
 
 // SelectFile 选择单个文件
@@ -749,26 +1389,111 @@ func (a *App) GetTransferMode(configID string) (string, error) {
 
 // ============ 终端管理 ============
 
-// terminalSessions 存储活动的终端会话
+// terminalSessions 存储活动的终端会话，按终端 ID 索引，同一连接可以打开多个终端
 var terminalSessions = make(map[string]*TerminalSession)
 var terminalSessionsMutex sync.RWMutex
 
+// terminalBacklogSize 每个终端保留的输出字节数上限，前端重新加载后从中恢复
+const terminalBacklogSize = 256 * 1024
+
 // TerminalSession 终端会话结构
 type TerminalSession struct {
+	ID         string
 	SSHSession *gossh.Session
 	Stdin      io.WriteCloser
 	ConfigID   string
+	CreatedAt  time.Time
+
+	backlog     []models.TerminalOutput // 最近的输出，按序号递增
+	backlogSize int
+	seq         int64 // 最后一段输出的序号
+	backlogMu   sync.Mutex
+	emitMu      sync.Mutex // 保证 terminal-output 事件按序号顺序发送，发送时不占用 backlogMu
 }
 
-// SendTerminalInput 发送终端输入
-func (a *App) SendTerminalInput(configID, input string) error {
+// emitOutput 记录一段输出并发送 terminal-output 事件
+func (t *TerminalSession) emitOutput(ctx context.Context, outputType, output string) {
+	t.emitMu.Lock()
+	defer t.emitMu.Unlock()
+
+	seq := t.appendOutput(outputType, output)
+	runtime.EventsEmit(ctx, "terminal-output", map[string]interface{}{
+		"terminalId": t.ID,
+		"configId":   t.ConfigID,
+		"seq":        seq,
+		"output":     output,
+		"type":       outputType,
+	})
+}
+
+// appendOutput 把一段输出加入缓存并返回它的序号，超出上限时丢弃最早的输出，至少保留最新一段
+func (t *TerminalSession) appendOutput(outputType, output string) int64 {
+	t.backlogMu.Lock()
+	defer t.backlogMu.Unlock()
+
+	t.seq++
+	t.backlog = append(t.backlog, models.TerminalOutput{Seq: t.seq, Type: outputType, Output: output})
+	t.backlogSize += len(output)
+
+	drop := 0
+	for t.backlogSize > terminalBacklogSize && drop < len(t.backlog)-1 {
+		t.backlogSize -= len(t.backlog[drop].Output)
+		drop++
+	}
+	t.backlog = t.backlog[drop:]
+	return t.seq
+}
+
+// backlogSince 返回序号大于 since 的缓存输出
+func (t *TerminalSession) backlogSince(since int64) models.TerminalBacklog {
+	t.backlogMu.Lock()
+	defer t.backlogMu.Unlock()
+
+	result := models.TerminalBacklog{
+		TerminalID: t.ID,
+		ConfigID:   t.ConfigID,
+		LastSeq:    t.seq,
+		Output:     []models.TerminalOutput{},
+	}
+	if len(t.backlog) == 0 {
+		return result
+	}
+
+	result.FirstSeq = t.backlog[0].Seq
+	result.Truncated = since+1 < result.FirstSeq
+	for _, chunk := range t.backlog {
+		if chunk.Seq > since {
+			result.Output = append(result.Output, chunk)
+		}
+	}
+	return result
+}
+
+// newTerminalID 生成终端 ID
+func newTerminalID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// getTerminalSession 按终端 ID 获取终端会话
+func getTerminalSession(terminalID string) (*TerminalSession, error) {
 	terminalSessionsMutex.RLock()
-	terminalSession, exists := terminalSessions[configID]
-	terminalSessionsMutex.RUnlock()
+	defer terminalSessionsMutex.RUnlock()
 
+	terminalSession, exists := terminalSessions[terminalID]
 	if !exists {
-		fmt.Printf("SendTerminalInput: 终端会话不存在，配置ID: %s\n", configID)
-		return fmt.Errorf("终端会话不存在")
+		return nil, fmt.Errorf("终端会话不存在")
+	}
+	return terminalSession, nil
+}
+
+// SendTerminalInput 发送终端输入
+func (a *App) SendTerminalInput(terminalID, input string) error {
+	terminalSession, err := getTerminalSession(terminalID)
+	if err != nil {
+		fmt.Printf("SendTerminalInput: 终端会话不存在，终端ID: %s\n", terminalID)
+		return err
 	}
 
 	// 记录输入（但不记录敏感信息）
@@ -778,54 +1503,44 @@ func (a *App) SendTerminalInput(configID, input string) error {
 		fmt.Printf("SendTerminalInput: 发送输入 [%d bytes]\n", len(input))
 	}
 
-	_, err := terminalSession.Stdin.Write([]byte(input))
+	_, err = terminalSession.Stdin.Write([]byte(input))
 	if err != nil {
 		fmt.Printf("SendTerminalInput: 写入失败: %v\n", err)
 	}
 	return err
 }
 
-// CreateInteractiveTerminal 创建交互式终端会话
-func (a *App) CreateInteractiveTerminal(configID string) error {
+// CreateInteractiveTerminal 在会话连接上创建新的交互式终端，返回终端 ID
+func (a *App) CreateInteractiveTerminal(configID string) (string, error) {
 	fmt.Printf("CreateInteractiveTerminal: 开始创建交互式终端，配置ID: %s\n", configID)
 
-	// 检查是否已经有交互式会话
-	terminalSessionsMutex.RLock()
-	if _, exists := terminalSessions[configID]; exists {
-		terminalSessionsMutex.RUnlock()
-		fmt.Printf("CreateInteractiveTerminal: 交互式会话已存在，先关闭旧会话\n")
-		a.CloseTerminalSession(configID)
-	} else {
-		terminalSessionsMutex.RUnlock()
-	}
-
 	session, err := a.sessionManager.GetSession(configID)
 	if err != nil {
 		fmt.Printf("CreateInteractiveTerminal: 获取会话失败: %v\n", err)
-		return err
+		return "", err
 	}
 
 	// 测试SSH连接是否正常
-	testSession, err := session.SSHClient.NewSession()
+	testSession, err := session.Client().NewSession()
 	if err != nil {
 		fmt.Printf("CreateInteractiveTerminal: SSH连接测试失败: %v\n", err)
-		return fmt.Errorf("SSH连接不可用: %w", err)
+		return "", fmt.Errorf("SSH连接不可用: %w", err)
 	}
 
 	// 运行一个简单命令测试连接
 	if err := testSession.Run("echo 'connection test'"); err != nil {
 		testSession.Close()
 		fmt.Printf("CreateInteractiveTerminal: SSH连接功能测试失败: %v\n", err)
-		return fmt.Errorf("SSH连接功能异常: %w", err)
+		return "", fmt.Errorf("SSH连接功能异常: %w", err)
 	}
 	testSession.Close()
 	fmt.Printf("CreateInteractiveTerminal: SSH连接测试通过\n")
 
 	// 创建交互式会话
-	sshSession, err := session.SSHClient.NewSession()
+	sshSession, err := session.Client().NewSession()
 	if err != nil {
 		fmt.Printf("CreateInteractiveTerminal: 创建SSH会话失败: %v\n", err)
-		return err
+		return "", err
 	}
 
 	// 设置终端模式 - 为交互式程序优化
@@ -855,11 +1570,21 @@ func (a *App) CreateInteractiveTerminal(configID string) error {
 		// 不要因为这个失败就退出，继续尝试
 	}
 
+	// 按配置请求 agent 转发，使终端内的 git 等命令可以使用本地密钥
+	if err := session.RequestAgentForwarding(sshSession); err != nil {
+		fmt.Printf("CreateInteractiveTerminal: 请求agent转发失败: %v\n", err)
+	}
+
+	// 按配置请求 X11 转发，远程图形程序显示在本地
+	if err := session.RequestX11Forwarding(sshSession); err != nil {
+		fmt.Printf("CreateInteractiveTerminal: 请求X11转发失败: %v\n", err)
+	}
+
 	// 请求伪终端 - 使用更大的默认尺寸
 	if err := sshSession.RequestPty("xterm-256color", 120, 30, modes); err != nil {
 		fmt.Printf("CreateInteractiveTerminal: 请求伪终端失败: %v\n", err)
 		sshSession.Close()
-		return err
+		return "", err
 	}
 
 	fmt.Printf("CreateInteractiveTerminal: 伪终端创建成功\n")
@@ -868,29 +1593,42 @@ func (a *App) CreateInteractiveTerminal(configID string) error {
 	stdin, err := sshSession.StdinPipe()
 	if err != nil {
 		sshSession.Close()
-		return err
+		return "", err
 	}
 
 	stdout, err := sshSession.StdoutPipe()
 	if err != nil {
 		sshSession.Close()
-		return err
+		return "", err
 	}
 
 	stderr, err := sshSession.StderrPipe()
 	if err != nil {
 		sshSession.Close()
-		return err
+		return "", err
 	}
 
 	// 启动 shell
 	if err := sshSession.Shell(); err != nil {
 		fmt.Printf("CreateInteractiveTerminal: 启动Shell失败: %v\n", err)
 		sshSession.Close()
-		return err
+		return "", err
 	}
 
-	fmt.Printf("CreateInteractiveTerminal: Shell启动成功\n")
+	terminalID := newTerminalID()
+	fmt.Printf("CreateInteractiveTerminal: Shell启动成功，终端ID: %s\n", terminalID)
+
+	// 存储终端会话以供后续输入使用
+	terminalSession := &TerminalSession{
+		ID:         terminalID,
+		SSHSession: sshSession,
+		Stdin:      stdin,
+		ConfigID:   configID,
+		CreatedAt:  time.Now(),
+	}
+	terminalSessionsMutex.Lock()
+	terminalSessions[terminalID] = terminalSession
+	terminalSessionsMutex.Unlock()
 
 	// 启动输出读取协程
 	go func() {
@@ -910,13 +1648,8 @@ func (a *App) CreateInteractiveTerminal(configID string) error {
 					break
 				}
 				if n > 0 {
-					output := string(buf[:n])
 					// 立即发送输出，保持原始格式
-					runtime.EventsEmit(a.ctx, "terminal-output", map[string]interface{}{
-						"configId": configID,
-						"output":   output,
-						"type":     "stdout",
-					})
+					terminalSession.emitOutput(a.ctx, "stdout", string(buf[:n]))
 				}
 			}
 		}()
@@ -934,29 +1667,15 @@ func (a *App) CreateInteractiveTerminal(configID string) error {
 					break
 				}
 				if n > 0 {
-					output := string(buf[:n])
-					runtime.EventsEmit(a.ctx, "terminal-output", map[string]interface{}{
-						"configId": configID,
-						"output":   output,
-						"type":     "stderr",
-					})
+					terminalSession.emitOutput(a.ctx, "stderr", string(buf[:n]))
 				}
 			}
 		}()
 
-		// 存储终端会话以供后续输入使用
-		terminalSessionsMutex.Lock()
-		terminalSessions[configID] = &TerminalSession{
-			SSHSession: sshSession,
-			Stdin:      stdin,
-			ConfigID:   configID,
-		}
-		terminalSessionsMutex.Unlock()
-
 		// 会话结束时清理
 		defer func() {
 			terminalSessionsMutex.Lock()
-			delete(terminalSessions, configID)
+			delete(terminalSessions, terminalID)
 			terminalSessionsMutex.Unlock()
 		}()
 
@@ -965,26 +1684,28 @@ func (a *App) CreateInteractiveTerminal(configID string) error {
 
 		// 发送连接状态变化事件
 		runtime.EventsEmit(a.ctx, "terminal-status", map[string]interface{}{
-			"configId":  configID,
-			"connected": false,
+			"terminalId": terminalID,
+			"configId":   configID,
+			"connected":  false,
 		})
 	}()
 
 	// 发送连接成功事件
 	runtime.EventsEmit(a.ctx, "terminal-status", map[string]interface{}{
-		"configId":  configID,
-		"connected": true,
+		"terminalId": terminalID,
+		"configId":   configID,
+		"connected":  true,
 	})
 
-	return nil
+	return terminalID, nil
 }
 
 // CloseTerminalSession 关闭终端会话
-func (a *App) CloseTerminalSession(configID string) error {
+func (a *App) CloseTerminalSession(terminalID string) error {
 	terminalSessionsMutex.Lock()
 	defer terminalSessionsMutex.Unlock()
 
-	terminalSession, exists := terminalSessions[configID]
+	terminalSession, exists := terminalSessions[terminalID]
 	if !exists {
 		return fmt.Errorf("终端会话不存在")
 	}
@@ -1000,25 +1721,55 @@ func (a *App) CloseTerminalSession(configID string) error {
 	}
 
 	// 从映射中删除
-	delete(terminalSessions, configID)
+	delete(terminalSessions, terminalID)
 
 	return nil
 }
 
 // ResizeTerminal 调整终端大小
-func (a *App) ResizeTerminal(configID string, cols, rows int) error {
-	terminalSessionsMutex.RLock()
-	terminalSession, exists := terminalSessions[configID]
-	terminalSessionsMutex.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("终端会话不存在")
+func (a *App) ResizeTerminal(terminalID string, cols, rows int) error {
+	terminalSession, err := getTerminalSession(terminalID)
+	if err != nil {
+		return err
 	}
 
 	// 发送窗口大小变化信号
 	return terminalSession.SSHSession.WindowChange(rows, cols)
 }
 
+// GetTerminalBacklog 获取终端缓存的输出，sinceSeq 为前端已收到的最后序号，0 表示全部
+func (a *App) GetTerminalBacklog(terminalID string, sinceSeq int64) (models.TerminalBacklog, error) {
+	terminalSession, err := getTerminalSession(terminalID)
+	if err != nil {
+		return models.TerminalBacklog{}, err
+	}
+
+	return terminalSession.backlogSince(sinceSeq), nil
+}
+
+// ListTerminals 列出会话上打开的终端，configID 为空时列出全部
+func (a *App) ListTerminals(configID string) []models.TerminalInfo {
+	terminalSessionsMutex.RLock()
+	defer terminalSessionsMutex.RUnlock()
+
+	terminals := []models.TerminalInfo{}
+	for _, terminalSession := range terminalSessions {
+		if configID != "" && terminalSession.ConfigID != configID {
+			continue
+		}
+		terminals = append(terminals, models.TerminalInfo{
+			ID:        terminalSession.ID,
+			ConfigID:  terminalSession.ConfigID,
+			CreatedAt: terminalSession.CreatedAt,
+		})
+	}
+
+	sort.Slice(terminals, func(i, j int) bool {
+		return terminals[i].CreatedAt.Before(terminals[j].CreatedAt)
+	})
+	return terminals
+}
+
 // Shutdown 应用关闭时清理资源
 func (a *App) Shutdown() {
 	// 关闭所有会话
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerminalBacklog(t *testing.T) {
+	chunk := strings.Repeat("x", terminalBacklogSize/4)
+
+	tests := []struct {
+		name          string
+		outputs       []string
+		since         int64
+		wantSeqs      []int64
+		wantFirst     int64
+		wantLast      int64
+		wantTruncated bool
+	}{
+		{"没有输出", nil, 0, nil, 0, 0, false},
+		{"全部输出", []string{"a", "b", "c"}, 0, []int64{1, 2, 3}, 1, 3, false},
+		{"从中间恢复", []string{"a", "b", "c"}, 2, []int64{3}, 1, 3, false},
+		{"已是最新", []string{"a", "b", "c"}, 3, nil, 1, 3, false},
+		{"超出上限丢弃最早的输出", []string{chunk, chunk, chunk, chunk, "y"}, 0, []int64{2, 3, 4, 5}, 2, 5, true},
+		{"丢弃的部分已读过", []string{chunk, chunk, chunk, chunk, "y"}, 1, []int64{2, 3, 4, 5}, 2, 5, false},
+		{"单段超出上限仍保留", []string{"a", strings.Repeat("z", terminalBacklogSize+1)}, 0, []int64{2}, 2, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &TerminalSession{ID: "t1", ConfigID: "c1"}
+			for i, output := range tt.outputs {
+				if seq := session.appendOutput("stdout", output); seq != int64(i+1) {
+					t.Fatalf("第 %d 段输出的序号为 %d", i+1, seq)
+				}
+			}
+
+			backlog := session.backlogSince(tt.since)
+			var seqs []int64
+			for _, out := range backlog.Output {
+				seqs = append(seqs, out.Seq)
+			}
+			if len(seqs) != len(tt.wantSeqs) {
+				t.Fatalf("返回的序号为 %v，期望 %v", seqs, tt.wantSeqs)
+			}
+			for i := range seqs {
+				if seqs[i] != tt.wantSeqs[i] {
+					t.Fatalf("返回的序号为 %v，期望 %v", seqs, tt.wantSeqs)
+				}
+			}
+			if backlog.FirstSeq != tt.wantFirst || backlog.LastSeq != tt.wantLast || backlog.Truncated != tt.wantTruncated {
+				t.Fatalf("FirstSeq=%d LastSeq=%d Truncated=%v，期望 %d %d %v",
+					backlog.FirstSeq, backlog.LastSeq, backlog.Truncated, tt.wantFirst, tt.wantLast, tt.wantTruncated)
+			}
+			if backlog.TerminalID != "t1" || backlog.ConfigID != "c1" || backlog.Output == nil {
+				t.Fatalf("缓存信息不正确: %+v", backlog)
+			}
+			if session.backlogSize > terminalBacklogSize && len(session.backlog) > 1 {
+				t.Fatalf("缓存大小 %d 超出上限", session.backlogSize)
+			}
+		})
+	}
+}
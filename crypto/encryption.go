@@ -3,104 +3,258 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// 密文版本，作为密文的第一个字节
+const (
+	// CipherVersionArgon2 使用 Argon2id 派生的密钥加密
+	CipherVersionArgon2 byte = 1
+)
+
+// KDFParams 密钥派生参数，随加密文件的头部一起保存
+type KDFParams struct {
+	Algorithm string `json:"algorithm"` // 目前仅支持 argon2id
+	Time      uint32 `json:"time"`      // 迭代次数
+	Memory    uint32 `json:"memory"`    // 内存占用（KiB）
+	Threads   uint8  `json:"threads"`   // 并行度
+	Salt      []byte `json:"salt"`      // 每个文件独立的随机盐
+}
+
+// DefaultKDFParams 新建加密文件时使用的默认参数，可按机器性能调整
+var DefaultKDFParams = KDFParams{
+	Algorithm: "argon2id",
+	Time:      3,
+	Memory:    64 * 1024,
+	Threads:   4,
+}
+
+// 文件头中派生参数的允许范围，防止损坏或恶意构造的文件让派生耗尽内存或长时间卡住
+const (
+	maxKDFTime    = 16
+	maxKDFMemory  = 1024 * 1024 // 1 GiB（KiB）
+	maxKDFThreads = 16
+	minSaltSize   = 8
+	maxSaltSize   = 64
 )
 
-// deriveKey 从用户输入的密钥生成固定长度的加密密钥
-func deriveKey(userKey string) []byte {
+// Validate 检查派生参数是否在允许范围内
+func (p KDFParams) Validate() error {
+	if p.Algorithm != "argon2id" {
+		return fmt.Errorf("不支持的密钥派生算法: %s", p.Algorithm)
+	}
+	if len(p.Salt) < minSaltSize || len(p.Salt) > maxSaltSize {
+		return fmt.Errorf("密钥派生参数无效: 盐长度 %d", len(p.Salt))
+	}
+	if p.Time == 0 || p.Time > maxKDFTime {
+		return fmt.Errorf("密钥派生参数无效: 迭代次数 %d", p.Time)
+	}
+	if p.Threads == 0 || p.Threads > maxKDFThreads {
+		return fmt.Errorf("密钥派生参数无效: 并行度 %d", p.Threads)
+	}
+	// Argon2 要求每个并行通道至少 8 KiB
+	if p.Memory < 8*uint32(p.Threads) || p.Memory > maxKDFMemory {
+		return fmt.Errorf("密钥派生参数无效: 内存 %d KiB", p.Memory)
+	}
+	return nil
+}
+
+// NewKDFParams 使用默认参数和新的随机盐生成派生参数
+func NewKDFParams() (KDFParams, error) {
+	params := DefaultKDFParams
+	params.Salt = make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, params.Salt); err != nil {
+		return KDFParams{}, err
+	}
+	return params, nil
+}
+
+// DeriveKey 使用 Argon2id 从用户输入的密钥派生 AES-256 密钥
+func DeriveKey(userKey string, params KDFParams) ([]byte, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	return argon2.IDKey([]byte(userKey), params.Salt, params.Time, params.Memory, params.Threads, 32), nil
+}
+
+// keyVerifierContext 计算密钥校验值时使用的固定文本
+const keyVerifierContext = "ssh-mdzz-key-verifier"
+
+// KeyVerifier 用 Argon2id 派生的密钥对固定文本计算 HMAC-SHA256，作为可以离线保存的密钥校验值
+func KeyVerifier(userKey string, params KDFParams) (string, error) {
+	key, err := DeriveKey(userKey, params)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(keyVerifierContext))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// CheckKeyVerifier 检查密钥是否与校验值匹配
+func CheckKeyVerifier(userKey string, params KDFParams, verifier string) (bool, error) {
+	expected, err := base64.StdEncoding.DecodeString(verifier)
+	if err != nil {
+		return false, err
+	}
+
+	actual, err := KeyVerifier(userKey, params)
+	if err != nil {
+		return false, err
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(actual)
+	return hmac.Equal(decoded, expected), nil
+}
+
+// LegacyKeyHash 旧版本会话中保存的无盐 SHA-256 密钥哈希，仅用于迁移旧会话
+func LegacyKeyHash(userKey string) string {
+	hash := sha256.Sum256([]byte(userKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// deriveLegacyKey 旧版本使用的无盐 SHA-256 密钥派生，仅用于迁移旧文件
+func deriveLegacyKey(userKey string) []byte {
 	hash := sha256.Sum256([]byte(userKey))
 	return hash[:]
 }
 
-// Encrypt 使用 AES-256-GCM 加密数据
-func Encrypt(plaintext string, userKey string) (string, error) {
+// Encrypt 使用 AES-256-GCM 加密数据，密文格式为 base64(版本 | nonce | 密文)
+func Encrypt(plaintext string, key []byte) (string, error) {
 	if plaintext == "" {
 		return "", nil
 	}
 
-	key := deriveKey(userKey)
-
-	block, err := aes.NewCipher(key)
+	sealed, err := seal([]byte(plaintext), key, []byte{CipherVersionArgon2})
 	if err != nil {
 		return "", err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	return base64.StdEncoding.EncodeToString(append([]byte{CipherVersionArgon2}, sealed...)), nil
+}
+
+// Decrypt 使用 AES-256-GCM 解密带版本号的密文
+func Decrypt(ciphertext string, key []byte) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return "", err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	if len(data) == 0 {
+		return "", errors.New("ciphertext too short")
+	}
+	if data[0] != CipherVersionArgon2 {
+		return "", fmt.Errorf("不支持的密文版本: %d", data[0])
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	plaintext, err := open(data[1:], key, data[:1])
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
 }
 
-// Decrypt 使用 AES-256-GCM 解密数据
-func Decrypt(ciphertext string, userKey string) (string, error) {
+// DecryptLegacy 解密旧版本（无版本号、SHA-256 派生密钥）的密文
+func DecryptLegacy(ciphertext string, userKey string) (string, error) {
 	if ciphertext == "" {
 		return "", nil
 	}
 
-	key := deriveKey(userKey)
-
 	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
+	plaintext, err := open(data, deriveLegacyKey(userKey), nil)
 	if err != nil {
 		return "", err
 	}
+	return string(plaintext), nil
+}
+
+// seal 加密并返回 nonce | 密文，additionalData 参与认证
+func seal(plaintext, key, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
 
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", errors.New("ciphertext too short")
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
 	}
 
-	nonce, cipherData := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, cipherData, nil)
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open 解密 nonce | 密文
+func open(data, key, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(plaintext), nil
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, cipherData := data[:nonceSize], data[nonceSize:]
+	return gcm.Open(nil, nonce, cipherData, additionalData)
 }
 
-// EncryptConfig 加密 SSH 配置中的敏感信息
-func EncryptConfig(config *models.SSHConfig, userKey string) error {
-	if config.Password != "" {
-		encrypted, err := Encrypt(config.Password, userKey)
-		if err != nil {
+// DecryptConfigLegacy 解密旧版本加密的 SSH 配置，仅用于迁移旧文件
+func DecryptConfigLegacy(config *models.SSHConfig, userKey string) error {
+	decrypt := func(ciphertext string) (string, error) { return DecryptLegacy(ciphertext, userKey) }
+	if err := decryptFields(decrypt, &config.Password, &config.KeyPassphrase); err != nil {
+		return err
+	}
+
+	for i := range config.JumpHosts {
+		if err := decryptFields(decrypt, &config.JumpHosts[i].Password, &config.JumpHosts[i].KeyPassphrase); err != nil {
 			return err
 		}
-		config.Password = encrypted
 	}
+
 	return nil
 }
 
-// DecryptConfig 解密 SSH 配置中的敏感信息
-func DecryptConfig(config *models.SSHConfig, userKey string) error {
-	if config.Password != "" {
-		decrypted, err := Decrypt(config.Password, userKey)
+// decryptFields 原地解密多个字段，空字段保持不变
+func decryptFields(decrypt func(string) (string, error), fields ...*string) error {
+	for _, field := range fields {
+		if *field == "" {
+			continue
+		}
+		result, err := decrypt(*field)
 		if err != nil {
 			return err
 		}
-		config.Password = decrypted
+		*field = result
 	}
 	return nil
 }
@@ -0,0 +1,225 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+// testKDFParams 测试使用的低开销派生参数
+func testKDFParams(salt string) KDFParams {
+	return KDFParams{Algorithm: "argon2id", Time: 1, Memory: 64, Threads: 1, Salt: []byte(salt)}
+}
+
+func TestKDFParamsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(p *KDFParams)
+		wantErr bool
+	}{
+		{"默认参数", func(p *KDFParams) {}, false},
+		{"不支持的算法", func(p *KDFParams) { p.Algorithm = "scrypt" }, true},
+		{"盐太短", func(p *KDFParams) { p.Salt = []byte("short") }, true},
+		{"盐太长", func(p *KDFParams) { p.Salt = make([]byte, maxSaltSize+1) }, true},
+		{"迭代次数为 0", func(p *KDFParams) { p.Time = 0 }, true},
+		{"迭代次数过大", func(p *KDFParams) { p.Time = maxKDFTime + 1 }, true},
+		{"并行度为 0", func(p *KDFParams) { p.Threads = 0 }, true},
+		{"并行度过大", func(p *KDFParams) { p.Threads = maxKDFThreads + 1 }, true},
+		{"内存小于每通道 8 KiB", func(p *KDFParams) { p.Threads = 4; p.Memory = 31 }, true},
+		{"内存过大", func(p *KDFParams) { p.Memory = maxKDFMemory + 1 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := DefaultKDFParams
+			params.Salt = []byte("0123456789abcdef")
+			tt.modify(&params)
+			if err := params.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	base, err := DeriveKey("secret", testKDFParams("salt-one"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(base) != 32 {
+		t.Fatalf("密钥长度为 %d，期望 32", len(base))
+	}
+
+	tests := []struct {
+		name    string
+		userKey string
+		params  KDFParams
+		same    bool
+	}{
+		{"相同输入", "secret", testKDFParams("salt-one"), true},
+		{"不同的盐", "secret", testKDFParams("salt-two"), false},
+		{"不同的密钥", "Secret", testKDFParams("salt-one"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := DeriveKey(tt.userKey, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(key, base) != tt.same {
+				t.Fatalf("派生结果相同 = %v，期望 %v", !tt.same, tt.same)
+			}
+		})
+	}
+
+	if _, err := DeriveKey("secret", KDFParams{Algorithm: "argon2id"}); err == nil {
+		t.Fatal("无效参数应返回错误")
+	}
+}
+
+func TestKeyVerifier(t *testing.T) {
+	params := testKDFParams("verifier-salt")
+	verifier, err := KeyVerifier("secret", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		userKey  string
+		params   KDFParams
+		verifier string
+		want     bool
+		wantErr  bool
+	}{
+		{"正确的密钥", "secret", params, verifier, true, false},
+		{"错误的密钥", "wrong", params, verifier, false, false},
+		{"不同的盐", "secret", testKDFParams("other-salt"), verifier, false, false},
+		{"校验值不是 base64", "secret", params, "!!!", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := CheckKeyVerifier(tt.userKey, tt.params, tt.verifier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckKeyVerifier() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if ok != tt.want {
+				t.Fatalf("CheckKeyVerifier() = %v，期望 %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := DeriveKey("secret", testKDFParams("encrypt-salt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := DeriveKey("other", testKDFParams("encrypt-salt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := Encrypt("hello", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(ciphertext)
+	if raw[0] != CipherVersionArgon2 {
+		t.Fatalf("密文版本字节为 %d，期望 %d", raw[0], CipherVersionArgon2)
+	}
+
+	// 未知的版本字节和被篡改的密文都应拒绝解密
+	changed := append([]byte{}, raw...)
+	changed[0] = 2
+	tampered := append([]byte{}, raw...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		name       string
+		ciphertext string
+		key        []byte
+		want       string
+		wantErr    bool
+	}{
+		{"正确的密钥", ciphertext, key, "hello", false},
+		{"空密文", "", key, "", false},
+		{"错误的密钥", ciphertext, otherKey, "", true},
+		{"未知的版本字节", base64.StdEncoding.EncodeToString(changed), key, "", true},
+		{"密文被篡改", base64.StdEncoding.EncodeToString(tampered), key, "", true},
+		{"只有版本字节", base64.StdEncoding.EncodeToString([]byte{CipherVersionArgon2}), key, "", true},
+		{"不是 base64", "!!!", key, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(tt.ciphertext, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Decrypt() = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+
+	if empty, err := Encrypt("", key); err != nil || empty != "" {
+		t.Fatalf("空字符串加密结果为 %q, %v", empty, err)
+	}
+}
+
+// legacyEncrypt 按旧版本格式加密：SHA-256 派生密钥，没有版本字节
+func legacyEncrypt(t *testing.T, plaintext, userKey string) string {
+	t.Helper()
+
+	block, err := aes.NewCipher(deriveLegacyKey(userKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestDecryptLegacy(t *testing.T) {
+	ciphertext := legacyEncrypt(t, "old-password", "secret")
+
+	tests := []struct {
+		name       string
+		ciphertext string
+		userKey    string
+		want       string
+		wantErr    bool
+	}{
+		{"正确的密钥", ciphertext, "secret", "old-password", false},
+		{"错误的密钥", ciphertext, "wrong", "", true},
+		{"空密文", "", "secret", "", false},
+		{"密文太短", base64.StdEncoding.EncodeToString([]byte{1, 2, 3}), "secret", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptLegacy(tt.ciphertext, tt.userKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptLegacy() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("DecryptLegacy() = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+
+	// 新格式的密文不能被当作旧格式解密
+	key, _ := DeriveKey("secret", testKDFParams("legacy-salt"))
+	current, _ := Encrypt("hello", key)
+	if _, err := DecryptLegacy(current, "secret"); err == nil {
+		t.Fatal("旧格式解密新密文应失败")
+	}
+}
@@ -3,7 +3,7 @@ import { useConnectionStore } from '@/stores/connection'
 import { useTerminalStore } from '@/stores/terminal'
 import { useMessage } from 'naive-ui'
 import { formatDuration, formatTime } from '@/utils/format'
-import { KEEP_ALIVE_INTERVAL, SESSION_STATUS } from '@/utils/constants'
+import { SESSION_STATUS } from '@/utils/constants'
 
 export function useSession() {
   const connectionStore = useConnectionStore()
@@ -15,7 +15,6 @@ export function useSession() {
   const activeSessionId = ref(null)
   const sessionStats = ref({})
   const isLoading = ref(false)
-  const keepAliveInterval = ref(null)
 
   // 计算属性
   const activeSessions = computed(() => 
@@ -366,36 +365,6 @@ export function useSession() {
     }
   }
 
-  /**
-   * 启动保活机制
-   */
-  function startKeepAlive() {
-    if (keepAliveInterval.value) return
-
-    keepAliveInterval.value = setInterval(async () => {
-      for (const session of activeSessions.value) {
-        try {
-          // 发送保活命令
-          await window.go.main.App.ExecuteCommand(session.configId, 'echo keepalive')
-          session.lastActivity = new Date()
-        } catch (error) {
-          console.warn(`会话 ${session.configName} 保活失败:`, error)
-          session.status = SESSION_STATUS.ERROR
-        }
-      }
-    }, KEEP_ALIVE_INTERVAL)
-  }
-
-  /**
-   * 停止保活机制
-   */
-  function stopKeepAlive() {
-    if (keepAliveInterval.value) {
-      clearInterval(keepAliveInterval.value)
-      keepAliveInterval.value = null
-    }
-  }
-
   /**
    * 导出会话信息
    */
@@ -475,13 +444,8 @@ export function useSession() {
     }
   }, { deep: true })
 
-  // 启动保活和监控
-  startKeepAlive()
-
   // 组件卸载时清理
   onUnmounted(() => {
-    stopKeepAlive()
-    
     // 停止所有会话监控
     sessions.value.forEach(session => {
       stopSessionMonitoring(session.id)
@@ -519,10 +483,6 @@ export function useSession() {
     stopSessionMonitoring,
     exportSessions,
     getSessionSummary,
-    cleanupDisconnectedSessions,
-
-    // 保活相关
-    startKeepAlive,
-    stopKeepAlive
+    cleanupDisconnectedSessions
   }
 }
\ No newline at end of file
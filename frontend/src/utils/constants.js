@@ -89,9 +89,6 @@ export const FILE_SIZE_UNITS = ['B', 'KB', 'MB', 'GB', 'TB']
 // 默认端口
 export const DEFAULT_SSH_PORT = '22'
 
-// 心跳间隔（毫秒）
-export const KEEP_ALIVE_INTERVAL = 60000
-
 // 连接超时（毫秒）
 export const CONNECTION_TIMEOUT = 10000
 
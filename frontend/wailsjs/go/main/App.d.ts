@@ -2,6 +2,16 @@
 // This file is automatically generated. DO NOT EDIT
 import {models} from '../models';
 
+export function AddAuthorizedKey(arg1:string,arg2:string):Promise<boolean>;
+
+export function AddHostCertAuthority(arg1:string,arg2:string):Promise<void>;
+
+export function AnswerHostKeyPrompt(arg1:string,arg2:boolean):Promise<void>;
+
+export function AnswerKeyboardInteractive(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function AnswerPassphrasePrompt(arg1:string,arg2:string):Promise<void>;
+
 export function AutoRestoreSession():Promise<void>;
 
 export function BatchDownloadFiles(arg1:string,arg2:Array<Record<string, string>>,arg3:boolean):Promise<void>;
@@ -10,6 +20,8 @@ export function BatchUploadFiles(arg1:string,arg2:Array<Record<string, string>>,
 
 export function CanAutoRestore():Promise<boolean>;
 
+export function ChangeEncryptionKey(arg1:string,arg2:string):Promise<void>;
+
 export function CheckConnection(arg1:string):Promise<models.ConnectionStatus>;
 
 export function ClearSession():Promise<void>;
@@ -20,7 +32,7 @@ export function CloseTerminalSession(arg1:string):Promise<void>;
 
 export function ConnectSSH(arg1:string):Promise<models.ConnectionResult>;
 
-export function CreateInteractiveTerminal(arg1:string):Promise<void>;
+export function CreateInteractiveTerminal(arg1:string):Promise<string>;
 
 export function CreateRemoteDirectory(arg1:string,arg2:string,arg3:boolean):Promise<void>;
 
@@ -32,6 +44,8 @@ export function DeleteRemoteDirectory(arg1:string,arg2:string,arg3:boolean):Prom
 
 export function DeleteRemoteFile(arg1:string,arg2:string,arg3:boolean):Promise<void>;
 
+export function DeleteVaultKey(arg1:string):Promise<void>;
+
 export function DisconnectSSH(arg1:string):Promise<void>;
 
 export function DownloadFile(arg1:string,arg2:string,arg3:string,arg4:boolean):Promise<void>;
@@ -42,8 +56,20 @@ export function ExecuteSSHCommand(arg1:string,arg2:string):Promise<models.Comman
 
 export function ExecuteSudoCommand(arg1:string,arg2:string):Promise<string>;
 
+export function ExportBundle(arg1:Array<string>,arg2:string,arg3:string):Promise<void>;
+
+export function ExportSSHConfig(arg1:Array<string>):Promise<string>;
+
+export function ExportVaultKey(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function GenerateKeyPair(arg1:models.KeyGenOptions):Promise<models.GeneratedKey>;
+
 export function GetActiveSessions():Promise<Array<models.SSHSession>>;
 
+export function GetAppSettings():Promise<models.AppSettings>;
+
+export function GetCertificateInfo(arg1:string):Promise<models.CertificateInfo>;
+
 export function GetConfig(arg1:string):Promise<models.SSHConfig>;
 
 export function GetConfigs():Promise<Array<models.SSHConfig>>;
@@ -54,22 +80,54 @@ export function GetRemoteHome(arg1:string):Promise<string>;
 
 export function GetSessionUptime(arg1:string):Promise<string>;
 
+export function GetTerminalBacklog(arg1:string,arg2:number):Promise<models.TerminalBacklog>;
+
 export function GetTransferMode(arg1:string):Promise<string>;
 
 export function HasConfigs():Promise<boolean>;
 
 export function HasValidSession():Promise<boolean>;
 
+export function ImportBundle(arg1:string,arg2:string,arg3:string):Promise<models.ImportResult>;
+
+export function ImportSSHConfig(arg1:string,arg2:Array<string>):Promise<models.ImportResult>;
+
+export function ImportVaultKey(arg1:string,arg2:string,arg3:string):Promise<models.VaultKey>;
+
+export function InstallPublicKey(arg1:string,arg2:string,arg3:string,arg4:string):Promise<models.KeyInstallResult>;
+
 export function IsKeySet():Promise<boolean>;
 
 export function IsSessionActive(arg1:string):Promise<boolean>;
 
+export function ListAuthorizedKeys(arg1:string):Promise<Array<models.AuthorizedKeyEntry>>;
+
+export function ListConfigBackups():Promise<Array<models.BackupInfo>>;
+
+export function ListHostKeys(arg1:string):Promise<Array<models.HostKeyEntry>>;
+
 export function ListRemoteFiles(arg1:string,arg2:string):Promise<Array<models.FileInfo>>;
 
+export function ListTerminals(arg1:string):Promise<Array<models.TerminalInfo>>;
+
+export function ListTunnels(arg1:string):Promise<Array<models.TunnelInfo>>;
+
+export function ListVaultKeys():Promise<Array<models.VaultKey>>;
+
 export function OpenTerminal(arg1:string):Promise<void>;
 
+export function PreviewSSHConfigImport(arg1:string):Promise<Array<models.ImportPreviewItem>>;
+
+export function RemoveAuthorizedKey(arg1:string,arg2:string):Promise<number>;
+
+export function RemoveHostKey(arg1:string):Promise<void>;
+
+export function RepinHostKey(arg1:string):Promise<models.HostKeyEntry>;
+
 export function ResizeTerminal(arg1:string,arg2:number,arg3:number):Promise<void>;
 
+export function RestoreConfigBackup(arg1:string):Promise<void>;
+
 export function RestoreSession():Promise<void>;
 
 export function SaveConfig(arg1:models.SSHConfig):Promise<void>;
@@ -84,10 +142,24 @@ export function SelectMultipleFiles():Promise<Array<string>>;
 
 export function SendTerminalInput(arg1:string,arg2:string):Promise<void>;
 
+export function SetAuthorizedKeyDisabled(arg1:string,arg2:string,arg3:boolean):Promise<void>;
+
 export function SetEncryptionKey(arg1:string):Promise<void>;
 
+export function SetUseSystemKnownHosts(arg1:boolean):Promise<void>;
+
 export function Shutdown():Promise<void>;
 
+export function StartLocalForward(arg1:string,arg2:string,arg3:string):Promise<models.TunnelInfo>;
+
+export function StartRemoteForward(arg1:string,arg2:string,arg3:string):Promise<models.TunnelInfo>;
+
+export function StartSocksProxy(arg1:string,arg2:string,arg3:string,arg4:string):Promise<models.TunnelInfo>;
+
+export function StopTunnel(arg1:string):Promise<void>;
+
+export function SwitchToKeyAuth(arg1:string,arg2:string,arg3:string,arg4:string,arg5:boolean):Promise<void>;
+
 export function TestSSHConnection(arg1:string):Promise<void>;
 
 export function UploadFile(arg1:string,arg2:string,arg3:string,arg4:boolean):Promise<void>;
@@ -2,6 +2,26 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddAuthorizedKey(arg1, arg2) {
+  return window['go']['main']['App']['AddAuthorizedKey'](arg1, arg2);
+}
+
+export function AddHostCertAuthority(arg1, arg2) {
+  return window['go']['main']['App']['AddHostCertAuthority'](arg1, arg2);
+}
+
+export function AnswerHostKeyPrompt(arg1, arg2) {
+  return window['go']['main']['App']['AnswerHostKeyPrompt'](arg1, arg2);
+}
+
+export function AnswerKeyboardInteractive(arg1, arg2) {
+  return window['go']['main']['App']['AnswerKeyboardInteractive'](arg1, arg2);
+}
+
+export function AnswerPassphrasePrompt(arg1, arg2) {
+  return window['go']['main']['App']['AnswerPassphrasePrompt'](arg1, arg2);
+}
+
 export function AutoRestoreSession() {
   return window['go']['main']['App']['AutoRestoreSession']();
 }
@@ -18,6 +38,10 @@ export function CanAutoRestore() {
   return window['go']['main']['App']['CanAutoRestore']();
 }
 
+export function ChangeEncryptionKey(arg1, arg2) {
+  return window['go']['main']['App']['ChangeEncryptionKey'](arg1, arg2);
+}
+
 export function CheckConnection(arg1) {
   return window['go']['main']['App']['CheckConnection'](arg1);
 }
@@ -62,6 +86,10 @@ export function DeleteRemoteFile(arg1, arg2, arg3) {
   return window['go']['main']['App']['DeleteRemoteFile'](arg1, arg2, arg3);
 }
 
+export function DeleteVaultKey(arg1) {
+  return window['go']['main']['App']['DeleteVaultKey'](arg1);
+}
+
 export function DisconnectSSH(arg1) {
   return window['go']['main']['App']['DisconnectSSH'](arg1);
 }
@@ -82,10 +110,34 @@ export function ExecuteSudoCommand(arg1, arg2) {
   return window['go']['main']['App']['ExecuteSudoCommand'](arg1, arg2);
 }
 
+export function ExportBundle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportBundle'](arg1, arg2, arg3);
+}
+
+export function ExportSSHConfig(arg1) {
+  return window['go']['main']['App']['ExportSSHConfig'](arg1);
+}
+
+export function ExportVaultKey(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportVaultKey'](arg1, arg2, arg3);
+}
+
+export function GenerateKeyPair(arg1) {
+  return window['go']['main']['App']['GenerateKeyPair'](arg1);
+}
+
 export function GetActiveSessions() {
   return window['go']['main']['App']['GetActiveSessions']();
 }
 
+export function GetAppSettings() {
+  return window['go']['main']['App']['GetAppSettings']();
+}
+
+export function GetCertificateInfo(arg1) {
+  return window['go']['main']['App']['GetCertificateInfo'](arg1);
+}
+
 export function GetConfig(arg1) {
   return window['go']['main']['App']['GetConfig'](arg1);
 }
@@ -106,6 +158,10 @@ export function GetSessionUptime(arg1) {
   return window['go']['main']['App']['GetSessionUptime'](arg1);
 }
 
+export function GetTerminalBacklog(arg1, arg2) {
+  return window['go']['main']['App']['GetTerminalBacklog'](arg1, arg2);
+}
+
 export function GetTransferMode(arg1) {
   return window['go']['main']['App']['GetTransferMode'](arg1);
 }
@@ -118,6 +174,22 @@ export function HasValidSession() {
   return window['go']['main']['App']['HasValidSession']();
 }
 
+export function ImportBundle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportBundle'](arg1, arg2, arg3);
+}
+
+export function ImportSSHConfig(arg1, arg2) {
+  return window['go']['main']['App']['ImportSSHConfig'](arg1, arg2);
+}
+
+export function ImportVaultKey(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportVaultKey'](arg1, arg2, arg3);
+}
+
+export function InstallPublicKey(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['InstallPublicKey'](arg1, arg2, arg3, arg4);
+}
+
 export function IsKeySet() {
   return window['go']['main']['App']['IsKeySet']();
 }
@@ -126,18 +198,62 @@ export function IsSessionActive(arg1) {
   return window['go']['main']['App']['IsSessionActive'](arg1);
 }
 
+export function ListAuthorizedKeys(arg1) {
+  return window['go']['main']['App']['ListAuthorizedKeys'](arg1);
+}
+
+export function ListConfigBackups() {
+  return window['go']['main']['App']['ListConfigBackups']();
+}
+
+export function ListHostKeys(arg1) {
+  return window['go']['main']['App']['ListHostKeys'](arg1);
+}
+
 export function ListRemoteFiles(arg1, arg2) {
   return window['go']['main']['App']['ListRemoteFiles'](arg1, arg2);
 }
 
+export function ListTerminals(arg1) {
+  return window['go']['main']['App']['ListTerminals'](arg1);
+}
+
+export function ListTunnels(arg1) {
+  return window['go']['main']['App']['ListTunnels'](arg1);
+}
+
+export function ListVaultKeys() {
+  return window['go']['main']['App']['ListVaultKeys']();
+}
+
 export function OpenTerminal(arg1) {
   return window['go']['main']['App']['OpenTerminal'](arg1);
 }
 
+export function PreviewSSHConfigImport(arg1) {
+  return window['go']['main']['App']['PreviewSSHConfigImport'](arg1);
+}
+
+export function RemoveAuthorizedKey(arg1, arg2) {
+  return window['go']['main']['App']['RemoveAuthorizedKey'](arg1, arg2);
+}
+
+export function RemoveHostKey(arg1) {
+  return window['go']['main']['App']['RemoveHostKey'](arg1);
+}
+
+export function RepinHostKey(arg1) {
+  return window['go']['main']['App']['RepinHostKey'](arg1);
+}
+
 export function ResizeTerminal(arg1, arg2, arg3) {
   return window['go']['main']['App']['ResizeTerminal'](arg1, arg2, arg3);
 }
 
+export function RestoreConfigBackup(arg1) {
+  return window['go']['main']['App']['RestoreConfigBackup'](arg1);
+}
+
 export function RestoreSession() {
   return window['go']['main']['App']['RestoreSession']();
 }
@@ -166,14 +282,42 @@ export function SendTerminalInput(arg1, arg2) {
   return window['go']['main']['App']['SendTerminalInput'](arg1, arg2);
 }
 
+export function SetAuthorizedKeyDisabled(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetAuthorizedKeyDisabled'](arg1, arg2, arg3);
+}
+
 export function SetEncryptionKey(arg1) {
   return window['go']['main']['App']['SetEncryptionKey'](arg1);
 }
 
+export function SetUseSystemKnownHosts(arg1) {
+  return window['go']['main']['App']['SetUseSystemKnownHosts'](arg1);
+}
+
 export function Shutdown() {
   return window['go']['main']['App']['Shutdown']();
 }
 
+export function StartLocalForward(arg1, arg2, arg3) {
+  return window['go']['main']['App']['StartLocalForward'](arg1, arg2, arg3);
+}
+
+export function StartRemoteForward(arg1, arg2, arg3) {
+  return window['go']['main']['App']['StartRemoteForward'](arg1, arg2, arg3);
+}
+
+export function StartSocksProxy(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['StartSocksProxy'](arg1, arg2, arg3, arg4);
+}
+
+export function StopTunnel(arg1) {
+  return window['go']['main']['App']['StopTunnel'](arg1);
+}
+
+export function SwitchToKeyAuth(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['SwitchToKeyAuth'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function TestSSHConnection(arg1) {
   return window['go']['main']['App']['TestSSHConnection'](arg1);
 }
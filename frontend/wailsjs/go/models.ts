@@ -1,5 +1,120 @@
 export namespace models {
 	
+	export class AppSettings {
+	    useSystemKnownHosts: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AppSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.useSystemKnownHosts = source["useSystemKnownHosts"];
+	    }
+	}
+	export class AuthorizedKeyEntry {
+	    type: string;
+	    fingerprint: string;
+	    comment: string;
+	    options?: string[];
+	    disabled: boolean;
+	    line: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuthorizedKeyEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.fingerprint = source["fingerprint"];
+	        this.comment = source["comment"];
+	        this.options = source["options"];
+	        this.disabled = source["disabled"];
+	        this.line = source["line"];
+	    }
+	}
+	export class BackupInfo {
+	    name: string;
+	    // Go type: time
+	    createdAt: any;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.size = source["size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CertificateInfo {
+	    type: string;
+	    keyId: string;
+	    serial: number;
+	    principals: string[];
+	    // Go type: time
+	    validAfter: any;
+	    // Go type: time
+	    validBefore: any;
+	    caKey: string;
+	    expired: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CertificateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.keyId = source["keyId"];
+	        this.serial = source["serial"];
+	        this.principals = source["principals"];
+	        this.validAfter = this.convertValues(source["validAfter"], null);
+	        this.validBefore = this.convertValues(source["validBefore"], null);
+	        this.caKey = source["caKey"];
+	        this.expired = source["expired"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class CommandResult {
 	    success: boolean;
 	    output: string;
@@ -164,7 +279,92 @@ export namespace models {
 		    return a;
 		}
 	}
+	export class Forward {
+	    type: string;
+	    listenAddr: string;
+	    targetAddr: string;
+	    autoStart: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Forward(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.listenAddr = source["listenAddr"];
+	        this.targetAddr = source["targetAddr"];
+	        this.autoStart = source["autoStart"];
+	    }
+	}
+	export class GeneratedKey {
+	    type: string;
+	    fingerprint: string;
+	    publicKey: string;
+	    privatePath?: string;
+	    vaultKeyId?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GeneratedKey(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.fingerprint = source["fingerprint"];
+	        this.publicKey = source["publicKey"];
+	        this.privatePath = source["privatePath"];
+	        this.vaultKeyId = source["vaultKeyId"];
+	    }
+	}
+	export class HostKeyEntry {
+	    host: string;
+	    keyType: string;
+	    fingerprint: string;
+	    marker: string;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HostKeyEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.keyType = source["keyType"];
+	        this.fingerprint = source["fingerprint"];
+	        this.marker = source["marker"];
+	        this.source = source["source"];
+	    }
+	}
+	export class JumpHost {
+	    configId: string;
+	    host: string;
+	    port: string;
+	    username: string;
+	    password: string;
+	    keyPath: string;
+	    keyId?: string;
+	    keyPassphrase: string;
+	    authType: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumpHost(source);
+	    }
 	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.configId = source["configId"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.keyPath = source["keyPath"];
+	        this.keyId = source["keyId"];
+	        this.keyPassphrase = source["keyPassphrase"];
+	        this.authType = source["authType"];
+	    }
+	}
 	export class SSHConfig {
 	    id: string;
 	    name: string;
@@ -173,7 +373,18 @@ export namespace models {
 	    username: string;
 	    password: string;
 	    keyPath: string;
+	    keyId?: string;
+	    keyPassphrase: string;
+	    certPath: string;
+	    authType: string;
+	    authMethods?: string[];
+	    agentForwarding: boolean;
+	    x11Forwarding: boolean;
 	    transferMode: string;
+	    jumpHosts?: JumpHost[];
+	    forwards?: Forward[];
+	    keepAliveInterval?: number;
+	    keepAliveMaxMissed?: number;
 	    // Go type: time
 	    createdAt: any;
 	    // Go type: time
@@ -192,7 +403,18 @@ export namespace models {
 	        this.username = source["username"];
 	        this.password = source["password"];
 	        this.keyPath = source["keyPath"];
+	        this.keyId = source["keyId"];
+	        this.keyPassphrase = source["keyPassphrase"];
+	        this.certPath = source["certPath"];
+	        this.authType = source["authType"];
+	        this.authMethods = source["authMethods"];
+	        this.agentForwarding = source["agentForwarding"];
+	        this.x11Forwarding = source["x11Forwarding"];
 	        this.transferMode = source["transferMode"];
+	        this.jumpHosts = this.convertValues(source["jumpHosts"], JumpHost);
+	        this.forwards = this.convertValues(source["forwards"], Forward);
+	        this.keepAliveInterval = source["keepAliveInterval"];
+	        this.keepAliveMaxMissed = source["keepAliveMaxMissed"];
 	        this.createdAt = this.convertValues(source["createdAt"], null);
 	        this.updatedAt = this.convertValues(source["updatedAt"], null);
 	    }
@@ -215,6 +437,158 @@ export namespace models {
 		    return a;
 		}
 	}
+	export class ImportPreviewItem {
+	    alias: string;
+	    action: string;
+	    reason: string;
+	    existingId: string;
+	    config: SSHConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportPreviewItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.alias = source["alias"];
+	        this.action = source["action"];
+	        this.reason = source["reason"];
+	        this.existingId = source["existingId"];
+	        this.config = this.convertValues(source["config"], SSHConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImportResult {
+	    added: number;
+	    updated: number;
+	    skipped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added = source["added"];
+	        this.updated = source["updated"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	
+	export class KeyGenOptions {
+	    type: string;
+	    bits: number;
+	    comment: string;
+	    passphrase: string;
+	    savePath: string;
+	    saveToVault: boolean;
+	    name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KeyGenOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.bits = source["bits"];
+	        this.comment = source["comment"];
+	        this.passphrase = source["passphrase"];
+	        this.savePath = source["savePath"];
+	        this.saveToVault = source["saveToVault"];
+	        this.name = source["name"];
+	    }
+	}
+	export class KeyInstallResult {
+	    added: boolean;
+	    fingerprint: string;
+	    verified: boolean;
+	    verifyError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KeyInstallResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added = source["added"];
+	        this.fingerprint = source["fingerprint"];
+	        this.verified = source["verified"];
+	        this.verifyError = source["verifyError"];
+	    }
+	}
+	
+	
+	export class TunnelInfo {
+	    id: string;
+	    configId: string;
+	    type: string;
+	    listenAddr: string;
+	    targetAddr: string;
+	    status: string;
+	    activeConnections: number;
+	    totalConnections: number;
+	    bytesSent: number;
+	    bytesReceived: number;
+	    // Go type: time
+	    startedAt: any;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TunnelInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.configId = source["configId"];
+	        this.type = source["type"];
+	        this.listenAddr = source["listenAddr"];
+	        this.targetAddr = source["targetAddr"];
+	        this.status = source["status"];
+	        this.activeConnections = source["activeConnections"];
+	        this.totalConnections = source["totalConnections"];
+	        this.bytesSent = source["bytesSent"];
+	        this.bytesReceived = source["bytesReceived"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class SSHSession {
 	    id: string;
 	    configId: string;
@@ -223,6 +597,9 @@ export namespace models {
 	    // Go type: time
 	    connectedAt: any;
 	    isActive: boolean;
+	    state: string;
+	    rttMs: number;
+	    tunnels: TunnelInfo[];
 	
 	    static createFrom(source: any = {}) {
 	        return new SSHSession(source);
@@ -236,6 +613,145 @@ export namespace models {
 	        this.host = source["host"];
 	        this.connectedAt = this.convertValues(source["connectedAt"], null);
 	        this.isActive = source["isActive"];
+	        this.state = source["state"];
+	        this.rttMs = source["rttMs"];
+	        this.tunnels = this.convertValues(source["tunnels"], TunnelInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TerminalOutput {
+	    seq: number;
+	    type: string;
+	    output: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TerminalOutput(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.type = source["type"];
+	        this.output = source["output"];
+	    }
+	}
+	export class TerminalBacklog {
+	    terminalId: string;
+	    configId: string;
+	    firstSeq: number;
+	    lastSeq: number;
+	    truncated: boolean;
+	    output: TerminalOutput[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TerminalBacklog(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.terminalId = source["terminalId"];
+	        this.configId = source["configId"];
+	        this.firstSeq = source["firstSeq"];
+	        this.lastSeq = source["lastSeq"];
+	        this.truncated = source["truncated"];
+	        this.output = this.convertValues(source["output"], TerminalOutput);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TerminalInfo {
+	    id: string;
+	    configId: string;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new TerminalInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.configId = source["configId"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class VaultKey {
+	    id: string;
+	    name: string;
+	    type: string;
+	    fingerprint: string;
+	    publicKey: string;
+	    // Go type: time
+	    createdAt: any;
+	    usedBy?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new VaultKey(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.fingerprint = source["fingerprint"];
+	        this.publicKey = source["publicKey"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.usedBy = source["usedBy"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -4,26 +4,111 @@ import "time"
 
 // SSHConfig SSH 连接配置
 type SSHConfig struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	Host         string    `json:"host"`
-	Port         string    `json:"port"`
-	Username     string    `json:"username"`
-	Password     string    `json:"password"`     // 加密存储
-	KeyPath      string    `json:"keyPath"`      // 私钥文件路径
-	TransferMode string    `json:"transferMode"` // sftp 或 scp
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`
+	Host               string     `json:"host"`
+	Port               string     `json:"port"`
+	Username           string     `json:"username"`
+	Password           string     `json:"password"`                     // 加密存储
+	KeyPath            string     `json:"keyPath"`                      // 私钥文件路径
+	KeyID              string     `json:"keyId,omitempty"`              // 密钥库中的私钥，优先于 KeyPath
+	KeyPassphrase      string     `json:"keyPassphrase"`                // 私钥密码，加密存储
+	CertPath           string     `json:"certPath"`                     // OpenSSH 用户证书路径（通常为 私钥-cert.pub）
+	AuthType           string     `json:"authType"`                     // password、key、agent 或 keyboard-interactive，为空时按密钥/密码自动选择
+	AuthMethods        []string   `json:"authMethods,omitempty"`        // 多重认证时按顺序使用的认证方式，优先于 AuthType
+	AgentForwarding    bool       `json:"agentForwarding"`              // 交互式终端中转发本地 ssh-agent
+	X11Forwarding      bool       `json:"x11Forwarding"`                // 交互式终端中转发 X11 到本地 DISPLAY
+	TransferMode       string     `json:"transferMode"`                 // sftp 或 scp
+	JumpHosts          []JumpHost `json:"jumpHosts,omitempty"`          // 跳板机链，按连接顺序排列
+	Forwards           []Forward  `json:"forwards,omitempty"`           // 端口转发，AutoStart 的在连接后自动启动
+	KeepAliveInterval  int        `json:"keepAliveInterval,omitempty"`  // 保活间隔（秒），0 为默认 30 秒，负数关闭保活
+	KeepAliveMaxMissed int        `json:"keepAliveMaxMissed,omitempty"` // 连续未收到保活回复多少次后断开重连，0 为默认 3 次
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+}
+
+// JumpHost 跳板机配置，引用已保存的配置或直接填写连接信息
+type JumpHost struct {
+	ConfigID      string `json:"configId"` // 引用已保存的配置，非空时忽略下面的字段
+	Host          string `json:"host"`
+	Port          string `json:"port"`
+	Username      string `json:"username"`
+	Password      string `json:"password"` // 加密存储
+	KeyPath       string `json:"keyPath"`
+	KeyID         string `json:"keyId,omitempty"`
+	KeyPassphrase string `json:"keyPassphrase"` // 加密存储
+	AuthType      string `json:"authType"`
+}
+
+// Forward 端口转发配置
+type Forward struct {
+	Type       string `json:"type"`       // local（-L）、remote（-R）或 dynamic（-D，SOCKS5）
+	ListenAddr string `json:"listenAddr"` // 监听地址，local 在本机、remote 在服务器上，只写端口时监听 127.0.0.1
+	TargetAddr string `json:"targetAddr"` // 转发目标，local 从服务器连接、remote 从本机连接，dynamic 不需要
+	AutoStart  bool   `json:"autoStart"`
+}
+
+// TunnelInfo 运行中的端口转发状态
+type TunnelInfo struct {
+	ID                string    `json:"id"`
+	ConfigID          string    `json:"configId"`
+	Type              string    `json:"type"`
+	ListenAddr        string    `json:"listenAddr"`
+	TargetAddr        string    `json:"targetAddr"`
+	Status            string    `json:"status"` // running 或 stopped
+	ActiveConnections int64     `json:"activeConnections"`
+	TotalConnections  int64     `json:"totalConnections"`
+	BytesSent         int64     `json:"bytesSent"`     // 监听端发往目标的字节数
+	BytesReceived     int64     `json:"bytesReceived"` // 目标返回的字节数
+	StartedAt         time.Time `json:"startedAt"`
+	Error             string    `json:"error,omitempty"`
 }
 
 // SSHSession SSH 会话信息
 type SSHSession struct {
-	ID          string    `json:"id"`
-	ConfigID    string    `json:"configId"`
-	ConfigName  string    `json:"configName"`
-	Host        string    `json:"host"`
-	ConnectedAt time.Time `json:"connectedAt"`
-	IsActive    bool      `json:"isActive"`
+	ID          string       `json:"id"`
+	ConfigID    string       `json:"configId"`
+	ConfigName  string       `json:"configName"`
+	Host        string       `json:"host"`
+	ConnectedAt time.Time    `json:"connectedAt"`
+	IsActive    bool         `json:"isActive"`
+	State       string       `json:"state"`   // connected 或 reconnecting
+	RTTMs       float64      `json:"rttMs"`   // 最近一次保活的往返时间（毫秒），0 表示尚未测量
+	Tunnels     []TunnelInfo `json:"tunnels"` // 运行中的端口转发和 SOCKS 代理
+}
+
+// TerminalInfo 打开的交互式终端
+type TerminalInfo struct {
+	ID        string    `json:"id"`
+	ConfigID  string    `json:"configId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TerminalOutput 终端的一段输出
+type TerminalOutput struct {
+	Seq    int64  `json:"seq"`  // 单个终端内递增的序号
+	Type   string `json:"type"` // stdout 或 stderr
+	Output string `json:"output"`
+}
+
+// TerminalBacklog 终端缓存的输出，用于前端重新加载后恢复
+type TerminalBacklog struct {
+	TerminalID string           `json:"terminalId"`
+	ConfigID   string           `json:"configId"`
+	FirstSeq   int64            `json:"firstSeq"`  // 缓存中最早的序号，0 表示没有缓存
+	LastSeq    int64            `json:"lastSeq"`   // 最后一段输出的序号
+	Truncated  bool             `json:"truncated"` // 请求的部分输出已被丢弃
+	Output     []TerminalOutput `json:"output"`
+}
+
+// SessionState 会话连接状态变化
+type SessionState struct {
+	ConfigID    string `json:"configId"`
+	State       string `json:"state"`             // connected、reconnecting 或 failed
+	Attempt     int    `json:"attempt,omitempty"` // 当前重连次数
+	MaxAttempts int    `json:"maxAttempts,omitempty"`
+	RetryInMs   int64  `json:"retryInMs,omitempty"` // 距下次重连的毫秒数
+	Error       string `json:"error,omitempty"`
 }
 
 // FileInfo 文件信息
@@ -91,3 +176,101 @@ type FileListResult struct {
 	Files   []RemoteFile `json:"files"`
 	Error   string       `json:"error"`
 }
+
+// HostKeyEntry 已信任的主机密钥
+type HostKeyEntry struct {
+	Host        string `json:"host"`
+	KeyType     string `json:"keyType"`
+	Fingerprint string `json:"fingerprint"`
+	Marker      string `json:"marker"` // @cert-authority 或 @revoked
+	Source      string `json:"source"` // app: 应用自有文件, system: ~/.ssh/known_hosts
+}
+
+// CertificateInfo OpenSSH 证书信息
+type CertificateInfo struct {
+	Type        string    `json:"type"` // user 或 host
+	KeyID       string    `json:"keyId"`
+	Serial      uint64    `json:"serial"`
+	Principals  []string  `json:"principals"`
+	ValidAfter  time.Time `json:"validAfter"`
+	ValidBefore time.Time `json:"validBefore"` // 零值表示永久有效
+	CAKey       string    `json:"caKey"`       // 签发 CA 的指纹
+	Expired     bool      `json:"expired"`
+}
+
+// ImportPreviewItem 导入预览条目
+type ImportPreviewItem struct {
+	Alias      string    `json:"alias"`
+	Action     string    `json:"action"` // add、update 或 skip
+	Reason     string    `json:"reason"`
+	ExistingID string    `json:"existingId"` // 更新或重复时对应的已有配置
+	Config     SSHConfig `json:"config"`
+}
+
+// ImportResult 导入结果统计
+type ImportResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// AppSettings 后端保存的应用设置
+type AppSettings struct {
+	UseSystemKnownHosts bool `json:"useSystemKnownHosts"` // 同时信任 ~/.ssh/known_hosts 中的记录
+}
+
+// BackupInfo 配置文件备份信息
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	Size      int64     `json:"size"`
+}
+
+// VaultKey 密钥库中的私钥信息（不包含私钥内容）
+type VaultKey struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`        // 如 ssh-ed25519、ssh-rsa
+	Fingerprint string    `json:"fingerprint"` // SHA256 指纹
+	PublicKey   string    `json:"publicKey"`   // authorized_keys 格式的公钥
+	CreatedAt   time.Time `json:"createdAt"`
+	UsedBy      []string  `json:"usedBy,omitempty"` // 引用该密钥的配置 ID
+}
+
+// KeyGenOptions 生成密钥对的选项
+type KeyGenOptions struct {
+	Type        string `json:"type"`        // ed25519、rsa 或 ecdsa
+	Bits        int    `json:"bits"`        // rsa: 3072/4096，ecdsa: 256/384/521，0 为默认
+	Comment     string `json:"comment"`     // 公钥注释
+	Passphrase  string `json:"passphrase"`  // 写入文件时用于加密私钥，可为空
+	SavePath    string `json:"savePath"`    // 私钥文件路径，为空时不写入文件
+	SaveToVault bool   `json:"saveToVault"` // 保存到密钥库
+	Name        string `json:"name"`        // 密钥库中的名称
+}
+
+// GeneratedKey 生成的密钥对信息
+type GeneratedKey struct {
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"` // SHA256 指纹
+	PublicKey   string `json:"publicKey"`   // authorized_keys 格式的公钥
+	PrivatePath string `json:"privatePath,omitempty"`
+	VaultKeyID  string `json:"vaultKeyId,omitempty"`
+}
+
+// KeyInstallResult 安装公钥到远程主机的结果
+type KeyInstallResult struct {
+	Added       bool   `json:"added"` // false 表示公钥已存在
+	Fingerprint string `json:"fingerprint"`
+	Verified    bool   `json:"verified"` // 使用该密钥重新登录成功
+	VerifyError string `json:"verifyError,omitempty"`
+}
+
+// AuthorizedKeyEntry 远程 authorized_keys 中的一条公钥
+type AuthorizedKeyEntry struct {
+	Type        string   `json:"type"`
+	Fingerprint string   `json:"fingerprint"` // SHA256 指纹
+	Comment     string   `json:"comment"`
+	Options     []string `json:"options,omitempty"` // 如 from="..."、command="..."
+	Disabled    bool     `json:"disabled"`          // 被注释禁用
+	Line        string   `json:"line"`              // 原始内容（不含禁用注释）
+}
@@ -0,0 +1,53 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// errAgentUnavailable 本地没有可用的 ssh-agent 或其中没有密钥，配置了其他认证方式时跳过 agent 认证
+var errAgentUnavailable = errors.New("ssh-agent 不可用")
+
+// agentAuthMethod 使用本地 ssh-agent 中的密钥进行认证，返回的关闭函数应在握手结束后调用
+func agentAuthMethod() (ssh.AuthMethod, func(), error) {
+	conn, err := dialAgent()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	agentClient := agent.NewClient(conn)
+	signers, err := agentClient.Signers()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("%w: 读取密钥失败: %v", errAgentUnavailable, err)
+	}
+	if len(signers) == 0 {
+		conn.Close()
+		return nil, nil, fmt.Errorf("%w: 没有可用的密钥", errAgentUnavailable)
+	}
+
+	return ssh.PublicKeysCallback(agentClient.Signers), func() { conn.Close() }, nil
+}
+
+// setupAgentForwarding 在连接上注册 agent 转发通道处理，转发到本地 ssh-agent
+func setupAgentForwarding(client *ssh.Client) error {
+	if err := forwardAgent(client); err != nil {
+		return fmt.Errorf("注册 agent 转发失败: %w", err)
+	}
+	return nil
+}
+
+// RequestAgentForwarding 为终端会话请求 agent 转发（配置未开启时不做任何事）
+func (s *Session) RequestAgentForwarding(sshSession *ssh.Session) error {
+	if !s.Config.AgentForwarding {
+		return nil
+	}
+	if !s.agentForwarding.Load() {
+		return fmt.Errorf("agent 转发未就绪")
+	}
+
+	return agent.RequestAgentForwarding(sshSession)
+}
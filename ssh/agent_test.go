@@ -0,0 +1,40 @@
+package ssh
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"ssh-mdzz/models"
+)
+
+func TestAgentUnavailableFallsThrough(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", filepath.Join(t.TempDir(), "missing.sock"))
+
+	tests := []struct {
+		name        string
+		methods     []string
+		wantMethods int
+		wantErr     error
+	}{
+		{"跳过 agent 使用密码", []string{"agent", "password"}, 1, nil},
+		{"多重认证中跳过 agent", []string{"password", "agent", "keyboard-interactive"}, 2, nil},
+		{"只配置了 agent", []string{"agent"}, 0, errAgentUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.SSHConfig{Name: "test", Password: "secret", AuthMethods: tt.methods}
+			methods, cleanup, err := newAuthMethods(config, false)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newAuthMethods() 错误 = %v，期望 %v", err, tt.wantErr)
+			}
+			if cleanup != nil {
+				cleanup()
+			}
+			if len(methods) != tt.wantMethods {
+				t.Fatalf("生成了 %d 个认证方法，期望 %d", len(methods), tt.wantMethods)
+			}
+		})
+	}
+}
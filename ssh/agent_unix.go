@@ -0,0 +1,45 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// agentSocket 返回本地 ssh-agent 的套接字路径
+func agentSocket() (string, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return "", fmt.Errorf("%w: SSH_AUTH_SOCK 未设置", errAgentUnavailable)
+	}
+	return socket, nil
+}
+
+// dialAgent 连接本地 ssh-agent 的 Unix 套接字
+func dialAgent() (io.ReadWriteCloser, error) {
+	socket, err := agentSocket()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 连接失败: %v", errAgentUnavailable, err)
+	}
+	return conn, nil
+}
+
+// forwardAgent 将远端的 agent 请求逐个转发到本地 ssh-agent 套接字
+func forwardAgent(client *ssh.Client) error {
+	socket, err := agentSocket()
+	if err != nil {
+		return err
+	}
+	return agent.ForwardToRemote(client, socket)
+}
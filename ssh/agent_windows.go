@@ -0,0 +1,51 @@
+//go:build windows
+
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// openSSHAgentPipe Windows 自带 OpenSSH 的 ssh-agent 服务使用的命名管道
+const openSSHAgentPipe = `\\.\pipe\openssh-ssh-agent`
+
+// agentPipe 返回本地 ssh-agent 的命名管道路径，SSH_AUTH_SOCK 未设置时使用 OpenSSH 服务的默认管道
+func agentPipe() string {
+	if pipe := os.Getenv("SSH_AUTH_SOCK"); pipe != "" {
+		return pipe
+	}
+	return openSSHAgentPipe
+}
+
+// dialAgent 打开本地 ssh-agent 的命名管道
+func dialAgent() (io.ReadWriteCloser, error) {
+	pipe, err := os.OpenFile(agentPipe(), os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 连接失败: %v", errAgentUnavailable, err)
+	}
+	return pipe, nil
+}
+
+// forwardAgent 通过一个 agent 客户端转发远端的请求；命名管道以同步方式打开，
+// 不能像 Unix 套接字那样同时读写，由 agent 客户端逐个处理请求。连接断开时关闭管道
+func forwardAgent(client *ssh.Client) error {
+	pipe, err := dialAgent()
+	if err != nil {
+		return err
+	}
+
+	if err := agent.ForwardToAgent(client, agent.NewClient(pipe)); err != nil {
+		pipe.Close()
+		return err
+	}
+	go func() {
+		client.Wait()
+		pipe.Close()
+	}()
+	return nil
+}
@@ -0,0 +1,329 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"ssh-mdzz/models"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// withSFTP 使用会话的 SFTP 客户端执行操作，SCP 模式下临时创建 SFTP 通道
+func (s *Session) withSFTP(fn func(*sftp.Client) error) error {
+	if s.SFTP() != nil && s.SFTP().sftpClient != nil {
+		return fn(s.SFTP().sftpClient)
+	}
+
+	client, err := sftp.NewClient(s.Client())
+	if err != nil {
+		return fmt.Errorf("创建 SFTP 客户端失败: %w", err)
+	}
+	defer client.Close()
+	return fn(client)
+}
+
+// remoteAuthorizedKeysPath 确保远程 ~/.ssh 存在且权限为 0700，返回 authorized_keys 路径
+func remoteAuthorizedKeysPath(client *sftp.Client) (string, error) {
+	home, err := client.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("获取远程主目录失败: %w", err)
+	}
+
+	sshDir := path.Join(home, ".ssh")
+	if _, err := client.Stat(sshDir); err != nil {
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("读取 %s 失败: %w", sshDir, err)
+		}
+		if err := client.Mkdir(sshDir); err != nil {
+			return "", fmt.Errorf("创建 %s 失败: %w", sshDir, err)
+		}
+	}
+	if err := client.Chmod(sshDir, 0700); err != nil {
+		return "", fmt.Errorf("设置 %s 权限失败: %w", sshDir, err)
+	}
+
+	return path.Join(sshDir, "authorized_keys"), nil
+}
+
+// readRemoteFile 读取远程文件，文件不存在时返回空内容
+func readRemoteFile(client *sftp.Client, remotePath string) ([]byte, error) {
+	file, err := client.Open(remotePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// writeRemoteFileAtomic 先写入临时文件再重命名替换，避免写入中断导致文件损坏
+func writeRemoteFileAtomic(client *sftp.Client, remotePath string, data []byte, mode os.FileMode) error {
+	tmpPath := remotePath + ".ssh-mdzz-tmp"
+	file, err := client.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		client.Remove(tmpPath)
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		client.Remove(tmpPath)
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		client.Remove(tmpPath)
+		return err
+	}
+
+	// 优先使用 OpenSSH 的 posix-rename 扩展原子替换
+	if err := client.PosixRename(tmpPath, remotePath); err == nil {
+		return nil
+	}
+	// 目标文件不存在时普通 rename 即可成功
+	if err := client.Rename(tmpPath, remotePath); err == nil {
+		return nil
+	}
+
+	// 服务器不支持 posix-rename 且目标已存在：先把原文件改名保留，新文件就位后再删除，
+	// 任何一步失败都不删除原文件，临时文件保留以便手动恢复
+	oldPath := remotePath + ".ssh-mdzz-old"
+	client.Remove(oldPath)
+	if err := client.Rename(remotePath, oldPath); err != nil {
+		return fmt.Errorf("替换 %s 失败，新内容保留在 %s: %w", remotePath, tmpPath, err)
+	}
+	if err := client.Rename(tmpPath, remotePath); err != nil {
+		if restoreErr := client.Rename(oldPath, remotePath); restoreErr != nil {
+			return fmt.Errorf("替换 %s 失败且恢复失败，原文件在 %s，新内容在 %s: %w", remotePath, oldPath, tmpPath, err)
+		}
+		return fmt.Errorf("替换 %s 失败，新内容保留在 %s: %w", remotePath, tmpPath, err)
+	}
+	client.Remove(oldPath)
+	return nil
+}
+
+// authorizedKeysBackupSuffix 修改 authorized_keys 前保存的备份文件后缀
+const authorizedKeysBackupSuffix = ".ssh-mdzz-bak"
+
+// authorizedKeyDisabledMarker 禁用公钥时加在行首的注释标记，其他注释行不视为公钥
+const authorizedKeyDisabledMarker = "#ssh-mdzz-disabled "
+
+// authorizedKeyLine 解析 authorized_keys 中的一行，带禁用标记的公钥视为已禁用，普通注释忽略
+func authorizedKeyLine(line string) (*models.AuthorizedKeyEntry, bool) {
+	text := strings.TrimSpace(line)
+	disabled := false
+	if strings.HasPrefix(text, authorizedKeyDisabledMarker) {
+		text = strings.TrimSpace(strings.TrimPrefix(text, authorizedKeyDisabledMarker))
+		disabled = true
+	} else if strings.HasPrefix(text, "#") {
+		return nil, false
+	}
+	if text == "" {
+		return nil, false
+	}
+
+	pub, comment, options, _, err := ssh.ParseAuthorizedKey([]byte(text))
+	if err != nil {
+		return nil, false
+	}
+
+	return &models.AuthorizedKeyEntry{
+		Type:        pub.Type(),
+		Fingerprint: ssh.FingerprintSHA256(pub),
+		Comment:     comment,
+		Options:     options,
+		Disabled:    disabled,
+		Line:        text,
+	}, true
+}
+
+// updateAuthorizedKeys 读取远程 authorized_keys 并按 update 修改，修改前备份原文件
+func (s *Session) updateAuthorizedKeys(update func(lines []string) ([]string, bool, error)) error {
+	return s.withSFTP(func(client *sftp.Client) error {
+		authPath, err := remoteAuthorizedKeysPath(client)
+		if err != nil {
+			return err
+		}
+
+		content, err := readRemoteFile(client, authPath)
+		if err != nil {
+			return fmt.Errorf("读取 %s 失败: %w", authPath, err)
+		}
+
+		var lines []string
+		if text := strings.TrimRight(string(content), "\n"); text != "" {
+			lines = strings.Split(text, "\n")
+		}
+
+		lines, changed, err := update(lines)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			// 未修改时只修正文件权限
+			if content != nil {
+				return client.Chmod(authPath, 0600)
+			}
+			return nil
+		}
+
+		if content != nil {
+			if err := writeRemoteFileAtomic(client, authPath+authorizedKeysBackupSuffix, content, 0600); err != nil {
+				return fmt.Errorf("备份 %s 失败: %w", authPath, err)
+			}
+		}
+
+		data := strings.Join(lines, "\n")
+		if len(lines) > 0 {
+			data += "\n"
+		}
+		return writeRemoteFileAtomic(client, authPath, []byte(data), 0600)
+	})
+}
+
+// ListAuthorizedKeys 列出远程 ~/.ssh/authorized_keys 中的公钥（包括已禁用的）
+func (s *Session) ListAuthorizedKeys() ([]models.AuthorizedKeyEntry, error) {
+	var entries []models.AuthorizedKeyEntry
+	err := s.withSFTP(func(client *sftp.Client) error {
+		home, err := client.Getwd()
+		if err != nil {
+			return fmt.Errorf("获取远程主目录失败: %w", err)
+		}
+
+		content, err := readRemoteFile(client, path.Join(home, ".ssh", "authorized_keys"))
+		if err != nil {
+			return fmt.Errorf("读取 authorized_keys 失败: %w", err)
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			if entry, ok := authorizedKeyLine(line); ok {
+				entries = append(entries, *entry)
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// InstallPublicKey 将公钥追加到远程 ~/.ssh/authorized_keys，已存在相同公钥时不重复添加
+func (s *Session) InstallPublicKey(publicKey string) (bool, error) {
+	entry, ok := authorizedKeyLine(publicKey)
+	if !ok || entry.Disabled {
+		return false, fmt.Errorf("公钥格式错误")
+	}
+
+	added := false
+	err := s.updateAuthorizedKeys(func(lines []string) ([]string, bool, error) {
+		for _, line := range lines {
+			if existing, ok := authorizedKeyLine(line); ok && !existing.Disabled && existing.Fingerprint == entry.Fingerprint {
+				return lines, false, nil
+			}
+		}
+		added = true
+		return append(lines, entry.Line), true, nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if added {
+		fmt.Printf("InstallPublicKey: 已添加公钥 %s\n", entry.Fingerprint)
+	} else {
+		fmt.Printf("InstallPublicKey: 公钥 %s 已存在\n", entry.Fingerprint)
+	}
+	return added, nil
+}
+
+// AddAuthorizedKey 添加一行 authorized_keys（可包含 from=、command= 等选项），
+// 已有相同公钥但选项不同时改为新的选项，返回文件是否被修改
+func (s *Session) AddAuthorizedKey(line string) (bool, error) {
+	entry, ok := authorizedKeyLine(line)
+	if !ok || entry.Disabled {
+		return false, fmt.Errorf("公钥格式错误")
+	}
+
+	changed := false
+	err := s.updateAuthorizedKeys(func(lines []string) ([]string, bool, error) {
+		for i, l := range lines {
+			existing, ok := authorizedKeyLine(l)
+			if !ok || existing.Disabled || existing.Fingerprint != entry.Fingerprint {
+				continue
+			}
+			if slices.Equal(existing.Options, entry.Options) {
+				return lines, false, nil
+			}
+			lines[i] = entry.Line
+			changed = true
+			return lines, true, nil
+		}
+		changed = true
+		return append(lines, entry.Line), true, nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if changed {
+		fmt.Printf("AddAuthorizedKey: 已写入公钥 %s\n", entry.Fingerprint)
+	} else {
+		fmt.Printf("AddAuthorizedKey: 公钥 %s 已存在且选项相同\n", entry.Fingerprint)
+	}
+	return changed, nil
+}
+
+// RemoveAuthorizedKey 删除指纹匹配的公钥（包括已禁用的），返回删除的行数
+func (s *Session) RemoveAuthorizedKey(fingerprint string) (int, error) {
+	removed := 0
+	err := s.updateAuthorizedKeys(func(lines []string) ([]string, bool, error) {
+		kept := make([]string, 0, len(lines))
+		for _, line := range lines {
+			if entry, ok := authorizedKeyLine(line); ok && entry.Fingerprint == fingerprint {
+				removed++
+				continue
+			}
+			kept = append(kept, line)
+		}
+		if removed == 0 {
+			return nil, false, fmt.Errorf("未找到公钥: %s", fingerprint)
+		}
+		return kept, true, nil
+	})
+	return removed, err
+}
+
+// SetAuthorizedKeyDisabled 通过注释禁用或重新启用指纹匹配的公钥
+func (s *Session) SetAuthorizedKeyDisabled(fingerprint string, disabled bool) error {
+	return s.updateAuthorizedKeys(func(lines []string) ([]string, bool, error) {
+		found, changed := false, false
+		for i, line := range lines {
+			entry, ok := authorizedKeyLine(line)
+			if !ok || entry.Fingerprint != fingerprint {
+				continue
+			}
+			found = true
+			if entry.Disabled == disabled {
+				continue
+			}
+			if disabled {
+				lines[i] = authorizedKeyDisabledMarker + entry.Line
+			} else {
+				lines[i] = entry.Line
+			}
+			changed = true
+		}
+		if !found {
+			return nil, false, fmt.Errorf("未找到公钥: %s", fingerprint)
+		}
+		return lines, changed, nil
+	})
+}
@@ -0,0 +1,88 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// LoadCertificate 读取 OpenSSH 证书文件
+func LoadCertificate(certPath string) (*ssh.Certificate, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取证书文件失败: %w", err)
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析证书失败: %w", err)
+	}
+
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s 不是 OpenSSH 证书", certPath)
+	}
+	return cert, nil
+}
+
+// CertificateInfo 提取证书的主体和有效期信息
+func CertificateInfo(cert *ssh.Certificate) *models.CertificateInfo {
+	info := &models.CertificateInfo{
+		Type:       "user",
+		KeyID:      cert.KeyId,
+		Serial:     cert.Serial,
+		Principals: cert.ValidPrincipals,
+		CAKey:      ssh.FingerprintSHA256(cert.SignatureKey),
+	}
+	if cert.CertType == ssh.HostCert {
+		info.Type = "host"
+	}
+	if cert.ValidAfter != 0 {
+		info.ValidAfter = time.Unix(int64(cert.ValidAfter), 0)
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		info.ValidBefore = time.Unix(int64(cert.ValidBefore), 0)
+		info.Expired = time.Now().After(info.ValidBefore)
+	}
+	return info
+}
+
+// checkCertificateValidity 连接前检查用户证书类型和有效期
+func checkCertificateValidity(cert *ssh.Certificate) error {
+	if cert.CertType != ssh.UserCert {
+		return fmt.Errorf("证书不是用户证书")
+	}
+
+	now := time.Now()
+	if cert.ValidAfter != 0 && now.Before(time.Unix(int64(cert.ValidAfter), 0)) {
+		return fmt.Errorf("证书尚未生效（生效时间 %s）",
+			time.Unix(int64(cert.ValidAfter), 0).Format("2006-01-02 15:04:05"))
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity && !now.Before(time.Unix(int64(cert.ValidBefore), 0)) {
+		return fmt.Errorf("证书已于 %s 过期，请重新签发",
+			time.Unix(int64(cert.ValidBefore), 0).Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// certSigner 将私钥与证书组合为证书签名器
+func certSigner(signer ssh.Signer, certPath string) (ssh.Signer, error) {
+	cert, err := LoadCertificate(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCertificateValidity(cert); err != nil {
+		return nil, err
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("证书与私钥不匹配: %w", err)
+	}
+	return certSigner, nil
+}
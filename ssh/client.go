@@ -1,8 +1,8 @@
 package ssh
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"ssh-mdzz/models"
@@ -10,47 +10,141 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
-// CreateSSHClient 创建 SSH 客户端
+// ErrInteractionRequired 非交互连接时需要用户确认主机密钥或输入密码、验证码
+var ErrInteractionRequired = errors.New("需要用户交互")
+
+// CreateSSHClient 创建 SSH 客户端（配置了跳板机时逐跳建立连接）
 func CreateSSHClient(config *models.SSHConfig) (*ssh.Client, error) {
-	var authMethod ssh.AuthMethod
+	return createSSHClient(config, true)
+}
 
-	// 选择认证方式
-	if config.KeyPath != "" {
-		// 密钥认证
-		key, err := os.ReadFile(config.KeyPath)
-		if err != nil {
-			return nil, fmt.Errorf("读取密钥文件失败: %w", err)
-		}
+// createSSHClient 创建 SSH 客户端，interactive 为 false 时只使用已保存或本次运行缓存的凭据，
+// 需要用户确认或输入时返回 ErrInteractionRequired，供后台重连使用
+func createSSHClient(config *models.SSHConfig, interactive bool) (*ssh.Client, error) {
+	hops, err := resolveHops(config)
+	if err != nil {
+		return nil, err
+	}
 
-		signer, err := ssh.ParsePrivateKey(key)
-		if err != nil {
-			return nil, fmt.Errorf("解析密钥失败: %w", err)
-		}
+	return connectHops(hops, interactive)
+}
 
-		authMethod = ssh.PublicKeys(signer)
-	} else if config.Password != "" {
-		// 密码认证
-		authMethod = ssh.Password(config.Password)
-	} else {
-		return nil, fmt.Errorf("未提供认证信息")
+// newClientConfig 根据配置生成 SSH 客户端配置，返回的清理函数应在握手结束后调用
+func newClientConfig(config *models.SSHConfig, interactive bool) (*ssh.ClientConfig, func(), error) {
+	authMethods, cleanup, err := newAuthMethods(config, interactive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostKeyCallback := GetHostKeyManager().HostKeyCallback()
+	if !interactive {
+		hostKeyCallback = GetHostKeyManager().NonInteractiveHostKeyCallback()
 	}
 
 	// SSH 客户端配置
-	clientConfig := &ssh.ClientConfig{
+	return &ssh.ClientConfig{
 		User:            config.Username,
-		Auth:            []ssh.AuthMethod{authMethod},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 生产环境应该验证
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
 		Timeout:         10 * time.Second,
+	}, cleanup, nil
+}
+
+// newAuthMethods 按配置顺序生成认证方法，服务器要求多重认证时依次使用
+func newAuthMethods(config *models.SSHConfig, interactive bool) ([]ssh.AuthMethod, func(), error) {
+	names := config.AuthMethods
+	if len(names) == 0 {
+		names = []string{defaultAuthMethod(config)}
 	}
 
-	// 连接
-	addr := fmt.Sprintf("%s:%s", config.Host, config.Port)
-	client, err := ssh.Dial("tcp", addr, clientConfig)
-	if err != nil {
-		return nil, fmt.Errorf("SSH 连接失败: %w", err)
+	var authMethods []ssh.AuthMethod
+	var cleanups []func()
+	cleanup := func() {
+		for _, fn := range cleanups {
+			fn()
+		}
+	}
+
+	var skipped error
+	for _, name := range names {
+		authMethod, methodCleanup, err := newAuthMethod(config, name, interactive)
+		if errors.Is(err, errAgentUnavailable) && len(names) > 1 {
+			// agent 不可用时跳过，继续使用后面配置的认证方式
+			fmt.Printf("newAuthMethods: 跳过 agent 认证: %v\n", err)
+			skipped = err
+			continue
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		authMethods = append(authMethods, authMethod)
+		cleanups = append(cleanups, methodCleanup)
+	}
+
+	if len(authMethods) == 0 {
+		return nil, nil, skipped
+	}
+	return authMethods, cleanup, nil
+}
+
+// defaultAuthMethod 未配置多重认证时，按认证方式或已填写的信息选择
+func defaultAuthMethod(config *models.SSHConfig) string {
+	if config.AuthType != "" {
+		return config.AuthType
+	}
+	if config.KeyID != "" || config.KeyPath != "" {
+		return "key"
+	}
+	if config.Password != "" {
+		return "password"
+	}
+	return ""
+}
+
+// newAuthMethod 根据认证方式名称生成认证方法
+func newAuthMethod(config *models.SSHConfig, name string, interactive bool) (ssh.AuthMethod, func(), error) {
+	noop := func() {}
+
+	switch name {
+	case "agent":
+		return agentAuthMethod()
+	case "key", "publickey":
+		// 密钥认证，优先使用密钥库中的私钥
+		var signer ssh.Signer
+		var err error
+		switch {
+		case config.KeyID != "":
+			signer, err = loadVaultKeySigner(config.KeyID, config.KeyPassphrase, config.Name, interactive)
+		case config.KeyPath != "":
+			signer, err = loadKeySigner(config.KeyPath, config.KeyPassphrase, config.Name, interactive)
+		default:
+			return nil, nil, fmt.Errorf("未提供私钥文件")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		// 配置了证书时使用证书认证，过期证书直接拒绝连接
+		if config.CertPath != "" {
+			signer, err = certSigner(signer, config.CertPath)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return ssh.PublicKeys(signer), noop, nil
+	case "password":
+		// 密码认证
+		if config.Password == "" {
+			return nil, nil, fmt.Errorf("未提供密码")
+		}
+		return ssh.Password(config.Password), noop, nil
+	case "keyboard-interactive":
+		return keyboardInteractiveAuth(config, interactive), noop, nil
+	case "":
+		return nil, nil, fmt.Errorf("未提供认证信息")
 	}
 
-	return client, nil
+	return nil, nil, fmt.Errorf("不支持的认证方式: %s", name)
 }
 
 // ExecuteCommand 执行单个命令
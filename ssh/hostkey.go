@@ -0,0 +1,528 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ssh-mdzz/fileutil"
+	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyPrompt 首次连接未知主机或替换已保存的密钥时交给用户确认的信息
+type HostKeyPrompt struct {
+	Host        string   `json:"host"`
+	KeyType     string   `json:"keyType"`
+	Fingerprint string   `json:"fingerprint"`
+	Previous    []string `json:"previous,omitempty"` // 将被替换的已保存指纹，格式为 "类型 指纹"
+}
+
+// HostKeyMismatchError 主机密钥与已保存的记录不一致（可能存在中间人攻击）
+type HostKeyMismatchError struct {
+	Host        string
+	KeyType     string
+	Fingerprint string
+	Known       []string // 已保存的指纹，格式为 "文件:行号 指纹"
+}
+
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("主机 %s 的密钥已变更（当前 %s %s，已保存 %s），可能存在中间人攻击，已拒绝连接",
+		e.Host, e.KeyType, e.Fingerprint, strings.Join(e.Known, "; "))
+}
+
+// ErrHostKeyRejected 用户拒绝信任未知主机密钥
+var ErrHostKeyRejected = errors.New("用户拒绝信任该主机密钥")
+
+// errHostKeyUnknown known_hosts 中没有该主机的记录
+var errHostKeyUnknown = errors.New("未知主机")
+
+// errHostKeyCaptured 仅获取主机密钥时用于中断握手
+var errHostKeyCaptured = errors.New("已获取主机密钥")
+
+// HostKeyManager 主机密钥管理器，维护应用自有的 known_hosts 文件
+type HostKeyManager struct {
+	filePath            string
+	systemFilePath      string
+	useSystemKnownHosts bool
+	prompter            func(HostKeyPrompt) bool
+	mu                  sync.RWMutex
+	fileMu              sync.Mutex // 保护 known_hosts 文件读写
+	promptMu            sync.Mutex // 同一时间只弹出一个确认框
+}
+
+var globalHostKeyManager = newHostKeyManager()
+
+func newHostKeyManager() *HostKeyManager {
+	home, _ := os.UserHomeDir()
+	return &HostKeyManager{
+		filePath:       filepath.Join(home, ".ssh-mdzz-known_hosts"),
+		systemFilePath: filepath.Join(home, ".ssh", "known_hosts"),
+	}
+}
+
+// GetHostKeyManager 获取全局主机密钥管理器
+func GetHostKeyManager() *HostKeyManager {
+	return globalHostKeyManager
+}
+
+// SetPrompter 设置首次连接时的确认回调，返回 true 表示信任
+func (m *HostKeyManager) SetPrompter(prompter func(HostKeyPrompt) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompter = prompter
+}
+
+// SetUseSystemKnownHosts 设置是否同时信任 ~/.ssh/known_hosts 中的记录
+func (m *HostKeyManager) SetUseSystemKnownHosts(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.useSystemKnownHosts = enabled
+}
+
+// UseSystemKnownHosts 是否读取 ~/.ssh/known_hosts
+func (m *HostKeyManager) UseSystemKnownHosts() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.useSystemKnownHosts
+}
+
+// HostKeyCallback 返回用于 ssh.ClientConfig 的主机密钥校验回调
+func (m *HostKeyManager) HostKeyCallback() ssh.HostKeyCallback {
+	return m.verify
+}
+
+// NonInteractiveHostKeyCallback 返回不询问用户的校验回调，未知主机返回 ErrInteractionRequired
+func (m *HostKeyManager) NonInteractiveHostKeyCallback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if _, err := m.checkKnown(hostname, remote, key); !errors.Is(err, errHostKeyUnknown) {
+			return err
+		}
+		return fmt.Errorf("%w: 未知主机 %s（%s）", ErrInteractionRequired, hostname, ssh.FingerprintSHA256(key))
+	}
+}
+
+// verify 校验主机密钥：已知则通过，变更则拒绝，未知则询问用户
+func (m *HostKeyManager) verify(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	key, err := m.checkKnown(hostname, remote, key)
+	if !errors.Is(err, errHostKeyUnknown) {
+		return err
+	}
+
+	// 未知主机：串行询问，避免并发连接重复弹框
+	m.promptMu.Lock()
+	defer m.promptMu.Unlock()
+
+	// 等待期间可能已被其他连接确认
+	var keyErr *knownhosts.KeyError
+	if err := m.check(hostname, remote, key); err == nil {
+		return nil
+	} else if errors.As(err, &keyErr) && len(keyErr.Want) > 0 {
+		return newHostKeyMismatchError(hostname, key, keyErr.Want)
+	}
+
+	m.mu.RLock()
+	prompter := m.prompter
+	m.mu.RUnlock()
+
+	if prompter == nil {
+		return fmt.Errorf("未知主机 %s（%s），且无法确认主机密钥", hostname, ssh.FingerprintSHA256(key))
+	}
+
+	fmt.Printf("HostKeyManager: 未知主机 %s，等待用户确认指纹 %s\n", hostname, ssh.FingerprintSHA256(key))
+	if !prompter(HostKeyPrompt{
+		Host:        knownhosts.Normalize(hostname),
+		KeyType:     key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+	}) {
+		return ErrHostKeyRejected
+	}
+
+	return m.addKey(hostname, key)
+}
+
+// checkKnown 按已保存的记录校验密钥：已知返回 nil，变更返回 HostKeyMismatchError，
+// 未知返回 errHostKeyUnknown，同时返回后续确认应使用的密钥（主机证书退回为其中的普通密钥）
+func (m *HostKeyManager) checkKnown(hostname string, remote net.Addr, key ssh.PublicKey) (ssh.PublicKey, error) {
+	err := m.check(hostname, remote, key)
+	if err == nil {
+		return key, nil
+	}
+
+	// 主机证书不是由已信任的 CA（@cert-authority）签发或校验失败时，
+	// 与 OpenSSH 一样退回按证书内的普通密钥校验
+	if cert, ok := key.(*ssh.Certificate); ok {
+		fmt.Printf("HostKeyManager: 主机 %s 的证书校验失败（%v），按普通密钥校验\n", hostname, err)
+		key = cert.Key
+		if err = m.check(hostname, remote, key); err == nil {
+			return key, nil
+		}
+	}
+
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return key, err
+	}
+	if len(keyErr.Want) > 0 {
+		return key, newHostKeyMismatchError(hostname, key, keyErr.Want)
+	}
+	return key, errHostKeyUnknown
+}
+
+// check 使用当前的 known_hosts 文件校验密钥
+func (m *HostKeyManager) check(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	files := []string{}
+	if _, err := os.Stat(m.filePath); err == nil {
+		files = append(files, m.filePath)
+	}
+	if m.UseSystemKnownHosts() {
+		if _, err := os.Stat(m.systemFilePath); err == nil {
+			// 系统文件可能包含无法解析的条目，单独校验后再加入
+			if _, err := knownhosts.New(m.systemFilePath); err != nil {
+				fmt.Printf("HostKeyManager: 忽略无法解析的 %s: %v\n", m.systemFilePath, err)
+			} else {
+				files = append(files, m.systemFilePath)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return &knownhosts.KeyError{}
+	}
+
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return fmt.Errorf("读取 known_hosts 失败: %w", err)
+	}
+
+	err = callback(hostname, remote, key)
+	var keyErr *knownhosts.KeyError
+	if errors.As(err, &keyErr) && len(keyErr.Want) > 0 {
+		// knownhosts 把匹配的 @cert-authority 记录也算作已知密钥，
+		// 与 OpenSSH 一样，只有 CA 记录的主机出示普通密钥时视为未知主机而不是密钥变更
+		keyErr.Want = withoutCertAuthorities(keyErr.Want)
+	}
+	return err
+}
+
+// withoutCertAuthorities 去掉来自 @cert-authority 记录的已知密钥，调用方需持有 fileMu
+func withoutCertAuthorities(want []knownhosts.KnownKey) []knownhosts.KnownKey {
+	files := make(map[string][]string)
+	var kept []knownhosts.KnownKey
+	for _, k := range want {
+		lines, ok := files[k.Filename]
+		if !ok {
+			data, err := os.ReadFile(k.Filename)
+			if err != nil {
+				kept = append(kept, k)
+				continue
+			}
+			lines = strings.Split(string(data), "\n")
+			files[k.Filename] = lines
+		}
+		if k.Line >= 1 && k.Line <= len(lines) {
+			if marker, _, _, _, _, err := ssh.ParseKnownHosts([]byte(lines[k.Line-1])); err == nil && marker == "cert-authority" {
+				continue
+			}
+		}
+		kept = append(kept, k)
+	}
+	return kept
+}
+
+// addKey 将主机密钥追加到应用的 known_hosts 文件
+func (m *HostKeyManager) addKey(hostname string, key ssh.PublicKey) error {
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	f, err := os.OpenFile(m.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("写入 known_hosts 失败: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(knownhosts.Line([]string{hostname}, key) + "\n"); err != nil {
+		return fmt.Errorf("写入 known_hosts 失败: %w", err)
+	}
+
+	fmt.Printf("HostKeyManager: 已信任主机 %s (%s)\n", knownhosts.Normalize(hostname), ssh.FingerprintSHA256(key))
+	return nil
+}
+
+// AddCertAuthority 信任指定主机模式下由该 CA 签发的主机证书
+func (m *HostKeyManager) AddCertAuthority(hostPattern string, caKey ssh.PublicKey) error {
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	f, err := os.OpenFile(m.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("写入 known_hosts 失败: %w", err)
+	}
+	defer f.Close()
+
+	line := fmt.Sprintf("@cert-authority %s %s", hostPattern, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(caKey))))
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		return fmt.Errorf("写入 known_hosts 失败: %w", err)
+	}
+	return nil
+}
+
+// ListHostKeys 列出指定主机已保存的密钥
+func (m *HostKeyManager) ListHostKeys(host, port string) ([]models.HostKeyEntry, error) {
+	address := knownhosts.Normalize(net.JoinHostPort(host, port))
+
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	entries, err := readHostKeyEntries(m.filePath, address, "app")
+	if err != nil {
+		return nil, err
+	}
+
+	if m.UseSystemKnownHosts() {
+		systemEntries, err := readHostKeyEntries(m.systemFilePath, address, "system")
+		if err != nil {
+			fmt.Printf("HostKeyManager: 读取 %s 失败: %v\n", m.systemFilePath, err)
+		} else {
+			entries = append(entries, systemEntries...)
+		}
+	}
+
+	return entries, nil
+}
+
+// RemoveHostKeys 从应用的 known_hosts 中删除指定主机的密钥，返回删除条数
+func (m *HostKeyManager) RemoveHostKeys(host, port string) (int, error) {
+	address := knownhosts.Normalize(net.JoinHostPort(host, port))
+
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	return m.rewriteHostKeysNoLock(address, "")
+}
+
+// PinHostKey 用新密钥替换应用中保存的主机密钥，删除旧记录和写入新记录在同一次文件替换中完成
+func (m *HostKeyManager) PinHostKey(host, port string, key ssh.PublicKey) (*models.HostKeyEntry, error) {
+	address := net.JoinHostPort(host, port)
+
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	if _, err := m.rewriteHostKeysNoLock(knownhosts.Normalize(address), knownhosts.Line([]string{address}, key)); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("HostKeyManager: 已替换主机 %s 的密钥为 %s\n", knownhosts.Normalize(address), ssh.FingerprintSHA256(key))
+	return &models.HostKeyEntry{
+		Host:        knownhosts.Normalize(address),
+		KeyType:     key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+		Source:      "app",
+	}, nil
+}
+
+// rewriteHostKeysNoLock 删除应用 known_hosts 中 address 的普通密钥记录，newLine 非空时追加到末尾，
+// 先写入临时文件再重命名，中途失败不会丢失原有记录；返回删除条数，调用方需持有 fileMu
+func (m *HostKeyManager) rewriteHostKeysNoLock(address, newLine string) (int, error) {
+	data, err := os.ReadFile(m.filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	var kept bytes.Buffer
+	removed := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		marker, hosts, _, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		// @cert-authority 和 @revoked 条目通常覆盖多台主机，不随单台主机删除
+		if err == nil && marker == "" && matchHostPatterns(hosts, address) {
+			removed++
+			continue
+		}
+		kept.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	if removed == 0 && newLine == "" {
+		return 0, nil
+	}
+	if newLine != "" {
+		kept.WriteString(newLine + "\n")
+	}
+
+	if err := fileutil.WriteFileAtomic(m.filePath, kept.Bytes(), 0600); err != nil {
+		return 0, fmt.Errorf("写入 known_hosts 失败: %w", err)
+	}
+	return removed, nil
+}
+
+// FetchHostKey 连接主机并获取其当前的主机密钥（不进行认证，跳板机照常认证）
+func FetchHostKey(config *models.SSHConfig) (ssh.PublicKey, error) {
+	hops, err := resolveHops(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev *ssh.Client
+	if len(hops) > 1 {
+		prev, err = connectHops(hops[:len(hops)-1], true)
+		if err != nil {
+			return nil, err
+		}
+		defer prev.Close()
+	}
+
+	var hostKey ssh.PublicKey
+	clientConfig := &ssh.ClientConfig{
+		User: config.Username,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errHostKeyCaptured
+		},
+		Timeout: 10 * time.Second,
+	}
+
+	client, err := dialThrough(prev, net.JoinHostPort(config.Host, config.Port), clientConfig)
+	if client != nil {
+		client.Close()
+	}
+	if hostKey == nil {
+		return nil, fmt.Errorf("获取主机密钥失败: %w", err)
+	}
+	return hostKey, nil
+}
+
+// newHostKeyMismatchError 根据 knownhosts 的结果构造密钥变更错误
+func newHostKeyMismatchError(hostname string, key ssh.PublicKey, want []knownhosts.KnownKey) error {
+	known := make([]string, 0, len(want))
+	for _, k := range want {
+		known = append(known, fmt.Sprintf("%s:%d %s", k.Filename, k.Line, ssh.FingerprintSHA256(k.Key)))
+	}
+	return &HostKeyMismatchError{
+		Host:        knownhosts.Normalize(hostname),
+		KeyType:     key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+		Known:       known,
+	}
+}
+
+// readHostKeyEntries 读取 known_hosts 文件中与地址匹配的条目
+func readHostKeyEntries(path, address, source string) ([]models.HostKeyEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []models.HostKeyEntry
+	for len(data) > 0 {
+		marker, hosts, key, _, rest, err := ssh.ParseKnownHosts(data)
+		if err != nil {
+			break
+		}
+		data = rest
+
+		if !matchHostPatterns(hosts, address) {
+			continue
+		}
+		entries = append(entries, models.HostKeyEntry{
+			Host:        address,
+			KeyType:     key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Marker:      marker,
+			Source:      source,
+		})
+	}
+	return entries, nil
+}
+
+// matchHostPatterns 判断 known_hosts 的主机列表是否匹配地址（支持哈希和通配符）
+func matchHostPatterns(patterns []string, address string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		if !matchHostPattern(pattern, address) {
+			continue
+		}
+		if negate {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// matchHostPattern 匹配单个 known_hosts 主机模式
+func matchHostPattern(pattern, address string) bool {
+	if strings.HasPrefix(pattern, "|1|") {
+		parts := strings.Split(pattern[3:], "|")
+		if len(parts) != 2 {
+			return false
+		}
+		salt, err := base64.StdEncoding.DecodeString(parts[0])
+		if err != nil {
+			return false
+		}
+		hash, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte(address))
+		return hmac.Equal(mac.Sum(nil), hash)
+	}
+
+	if strings.ContainsAny(pattern, "*?") {
+		return wildcardMatch(pattern, address)
+	}
+
+	return knownhosts.Normalize(pattern) == address
+}
+
+// wildcardMatch 按 OpenSSH 规则匹配 * 和 ? 通配符
+func wildcardMatch(pattern, str string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := 0; i <= len(str); i++ {
+				if wildcardMatch(pattern[1:], str[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+		default:
+			if len(str) == 0 || pattern[0] != str[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		str = str[1:]
+	}
+	return len(str) == 0
+}
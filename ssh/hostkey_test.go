@@ -0,0 +1,370 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testHostSigner 生成一个用于主机密钥测试的 ed25519 签名器
+func testHostSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// testHostCert 用 ca 为 key 签发主机证书
+func testHostCert(t *testing.T, ca ssh.Signer, key ssh.PublicKey, principals ...string) *ssh.Certificate {
+	t.Helper()
+
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        ssh.HostCert,
+		ValidPrincipals: principals,
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// newTestHostKeyManager 返回使用临时 known_hosts 文件的管理器，lines 为文件内容
+func newTestHostKeyManager(t *testing.T, lines ...string) *HostKeyManager {
+	t.Helper()
+
+	dir := t.TempDir()
+	m := &HostKeyManager{
+		filePath:       filepath.Join(dir, "known_hosts"),
+		systemFilePath: filepath.Join(dir, "system_known_hosts"),
+	}
+	if len(lines) > 0 {
+		if err := os.WriteFile(m.filePath, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestMatchHostPattern(t *testing.T) {
+	hashed := knownhosts.HashHostname("example.com")
+	hashedPort := knownhosts.HashHostname("[example.com]:2222")
+
+	tests := []struct {
+		name    string
+		pattern string
+		address string
+		want    bool
+	}{
+		{"主机名相同", "example.com", "example.com", true},
+		{"主机名不同", "example.com", "example.org", false},
+		{"默认端口", "example.com:22", "example.com", true},
+		{"非默认端口", "[example.com]:2222", "[example.com]:2222", true},
+		{"端口不同", "[example.com]:2222", "example.com", false},
+		{"星号通配", "*.example.com", "web.example.com", true},
+		{"星号不匹配上级域名", "*.example.com", "example.com", false},
+		{"问号通配", "web?", "web1", true},
+		{"问号只匹配一个字符", "web?", "web12", false},
+		{"哈希主机名", hashed, "example.com", true},
+		{"哈希主机名不同", hashed, "example.org", false},
+		{"哈希带端口的地址", hashedPort, "[example.com]:2222", true},
+		{"哈希格式错误", "|1|bad", "example.com", false},
+		{"哈希不是 base64", "|1|!!!|!!!", "example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchHostPattern(tt.pattern, tt.address); got != tt.want {
+				t.Fatalf("matchHostPattern(%q, %q) = %v，期望 %v", tt.pattern, tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchHostPatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		address  string
+		want     bool
+	}{
+		{[]string{"web.example.com", "db.example.com"}, "db.example.com", true},
+		{[]string{"*.example.com", "!db.example.com"}, "web.example.com", true},
+		{[]string{"*.example.com", "!db.example.com"}, "db.example.com", false},
+		{[]string{"!db.example.com", "*.example.com"}, "db.example.com", false},
+		{[]string{"!db.example.com"}, "web.example.com", false},
+		{nil, "web.example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchHostPatterns(tt.patterns, tt.address); got != tt.want {
+			t.Errorf("%v 匹配 %q = %v，期望 %v", tt.patterns, tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestCheckKnown(t *testing.T) {
+	hostKey := testHostSigner(t).PublicKey()
+	otherKey := testHostSigner(t).PublicKey()
+	ca := testHostSigner(t)
+	untrustedCA := testHostSigner(t)
+
+	trustedCert := testHostCert(t, ca, hostKey, "example.com")
+	untrustedCert := testHostCert(t, untrustedCA, hostKey, "example.com")
+	caLine := "@cert-authority *.com " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(ca.PublicKey())))
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+
+	tests := []struct {
+		name     string
+		lines    []string
+		hostname string
+		key      ssh.PublicKey
+		wantKey  ssh.PublicKey // 后续确认应使用的密钥
+		wantErr  error         // nil、errHostKeyUnknown 或 *HostKeyMismatchError
+	}{
+		{
+			name:     "已知主机",
+			lines:    []string{knownhosts.Line([]string{"example.com:22"}, hostKey)},
+			hostname: "example.com:22",
+			key:      hostKey,
+			wantKey:  hostKey,
+		},
+		{
+			name:     "哈希记录",
+			lines:    []string{knownhosts.HashHostname("example.com") + " " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(hostKey)))},
+			hostname: "example.com:22",
+			key:      hostKey,
+			wantKey:  hostKey,
+		},
+		{
+			name:     "没有 known_hosts 文件",
+			hostname: "example.com:22",
+			key:      hostKey,
+			wantKey:  hostKey,
+			wantErr:  errHostKeyUnknown,
+		},
+		{
+			name:     "未知主机",
+			lines:    []string{knownhosts.Line([]string{"other.com:22"}, otherKey)},
+			hostname: "example.com:22",
+			key:      hostKey,
+			wantKey:  hostKey,
+			wantErr:  errHostKeyUnknown,
+		},
+		{
+			name:     "非默认端口只匹配带端口的记录",
+			lines:    []string{knownhosts.Line([]string{"example.com:22"}, hostKey)},
+			hostname: "example.com:2222",
+			key:      hostKey,
+			wantKey:  hostKey,
+			wantErr:  errHostKeyUnknown,
+		},
+		{
+			name:     "密钥变更",
+			lines:    []string{knownhosts.Line([]string{"example.com:22"}, otherKey)},
+			hostname: "example.com:22",
+			key:      hostKey,
+			wantKey:  hostKey,
+			wantErr:  &HostKeyMismatchError{},
+		},
+		{
+			name:     "受信任 CA 签发的证书",
+			lines:    []string{caLine},
+			hostname: "example.com:22",
+			key:      trustedCert,
+			wantKey:  trustedCert,
+		},
+		{
+			name:     "未受信任的证书退回按普通密钥校验",
+			lines:    []string{knownhosts.Line([]string{"example.com:22"}, hostKey)},
+			hostname: "example.com:22",
+			key:      untrustedCert,
+			wantKey:  hostKey,
+		},
+		{
+			name:     "未受信任的证书且普通密钥未知",
+			lines:    []string{caLine},
+			hostname: "example.com:22",
+			key:      untrustedCert,
+			wantKey:  hostKey,
+			wantErr:  errHostKeyUnknown,
+		},
+		{
+			name:     "未受信任的证书且普通密钥变更",
+			lines:    []string{knownhosts.Line([]string{"example.com:22"}, otherKey)},
+			hostname: "example.com:22",
+			key:      untrustedCert,
+			wantKey:  hostKey,
+			wantErr:  &HostKeyMismatchError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestHostKeyManager(t, tt.lines...)
+			key, err := m.checkKnown(tt.hostname, remote, tt.key)
+
+			var mismatch *HostKeyMismatchError
+			switch {
+			case tt.wantErr == nil:
+				if err != nil {
+					t.Fatalf("checkKnown() 错误 = %v，期望通过", err)
+				}
+			case errors.As(tt.wantErr, &mismatch):
+				if !errors.As(err, &mismatch) {
+					t.Fatalf("checkKnown() 错误 = %v，期望 HostKeyMismatchError", err)
+				}
+				if len(mismatch.Known) != 1 || !strings.Contains(mismatch.Known[0], ssh.FingerprintSHA256(otherKey)) {
+					t.Fatalf("HostKeyMismatchError.Known = %v，应列出已保存的指纹", mismatch.Known)
+				}
+			default:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("checkKnown() 错误 = %v，期望 %v", err, tt.wantErr)
+				}
+			}
+			if string(key.Marshal()) != string(tt.wantKey.Marshal()) {
+				t.Fatalf("checkKnown() 返回的密钥类型为 %s，期望 %s", key.Type(), tt.wantKey.Type())
+			}
+		})
+	}
+}
+
+func TestPinHostKey(t *testing.T) {
+	oldKey := testHostSigner(t).PublicKey()
+	newKey := testHostSigner(t).PublicKey()
+	otherKey := testHostSigner(t).PublicKey()
+	ca := testHostSigner(t).PublicKey()
+	authorized := func(key ssh.PublicKey) string { return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))) }
+
+	caLine := "@cert-authority *.example.com " + authorized(ca)
+	otherLine := knownhosts.Line([]string{"other.example.com:22"}, otherKey)
+	otherPortLine := knownhosts.Line([]string{"example.com:2222"}, otherKey)
+
+	tests := []struct {
+		name     string
+		lines    []string
+		host     string
+		port     string
+		wantKept []string // 除新记录外应保留的行
+	}{
+		{
+			name:     "没有旧记录",
+			host:     "example.com",
+			port:     "22",
+			wantKept: nil,
+		},
+		{
+			name:     "替换普通记录",
+			lines:    []string{knownhosts.Line([]string{"example.com:22"}, oldKey), otherLine},
+			host:     "example.com",
+			port:     "22",
+			wantKept: []string{otherLine},
+		},
+		{
+			name: "替换哈希和通配符记录",
+			lines: []string{
+				knownhosts.HashHostname("example.com") + " " + authorized(oldKey),
+				"*.com " + authorized(oldKey),
+				otherLine,
+			},
+			host:     "example.com",
+			port:     "22",
+			wantKept: []string{otherLine},
+		},
+		{
+			name:     "保留 CA、注释和其他端口",
+			lines:    []string{"# 注释", caLine, knownhosts.Line([]string{"web.example.com:22"}, oldKey), otherPortLine},
+			host:     "web.example.com",
+			port:     "22",
+			wantKept: []string{"# 注释", caLine, otherPortLine},
+		},
+		{
+			name:     "替换非默认端口",
+			lines:    []string{knownhosts.Line([]string{"example.com:22"}, oldKey), otherPortLine},
+			host:     "example.com",
+			port:     "2222",
+			wantKept: []string{knownhosts.Line([]string{"example.com:22"}, oldKey)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestHostKeyManager(t, tt.lines...)
+			entry, err := m.PinHostKey(tt.host, tt.port, newKey)
+			if err != nil {
+				t.Fatalf("PinHostKey: %v", err)
+			}
+			if entry.Fingerprint != ssh.FingerprintSHA256(newKey) {
+				t.Fatalf("返回的指纹为 %s，期望 %s", entry.Fingerprint, ssh.FingerprintSHA256(newKey))
+			}
+
+			data, err := os.ReadFile(m.filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := append(append([]string{}, tt.wantKept...), knownhosts.Line([]string{net.JoinHostPort(tt.host, tt.port)}, newKey))
+			if got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Fatalf("known_hosts 内容为\n%s\n期望\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+
+			entries, err := m.ListHostKeys(tt.host, tt.port)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for _, e := range entries {
+				if e.Marker == "" {
+					keys = append(keys, e.Fingerprint)
+				}
+			}
+			if len(keys) != 1 || keys[0] != ssh.FingerprintSHA256(newKey) {
+				t.Fatalf("替换后主机的记录为 %+v，应只有新密钥", entries)
+			}
+		})
+	}
+}
+
+func TestRemoveHostKeys(t *testing.T) {
+	key := testHostSigner(t).PublicKey()
+	otherLine := knownhosts.Line([]string{"other.example.com:22"}, key)
+	m := newTestHostKeyManager(t,
+		knownhosts.Line([]string{"example.com:22"}, key),
+		knownhosts.HashHostname("example.com")+" "+strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))),
+		otherLine,
+	)
+
+	removed, err := m.RemoveHostKeys("example.com", "22")
+	if err != nil {
+		t.Fatalf("RemoveHostKeys: %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("删除了 %d 条记录，期望 2", removed)
+	}
+	data, err := os.ReadFile(m.filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != otherLine+"\n" {
+		t.Fatalf("删除后 known_hosts 内容为 %q，应只保留其他主机", data)
+	}
+
+	// 没有匹配的记录时不重写文件
+	if removed, err := m.RemoveHostKeys("example.com", "22"); err != nil || removed != 0 {
+		t.Fatalf("再次删除返回 %d, %v，期望 0, nil", removed, err)
+	}
+}
@@ -0,0 +1,82 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyboardInteractivePrompt 服务器发起的 keyboard-interactive 质询
+type KeyboardInteractivePrompt struct {
+	ConfigName  string   `json:"configName"`
+	Host        string   `json:"host"`
+	Name        string   `json:"name"`
+	Instruction string   `json:"instruction"`
+	Questions   []string `json:"questions"`
+	Echos       []bool   `json:"echos"` // 对应问题的输入是否可以明文显示
+}
+
+var (
+	interactivePrompter   func(KeyboardInteractivePrompt) ([]string, error)
+	interactivePrompterMu sync.RWMutex
+)
+
+// SetKeyboardInteractivePrompter 设置 keyboard-interactive 质询的应答回调
+func SetKeyboardInteractivePrompter(prompter func(KeyboardInteractivePrompt) ([]string, error)) {
+	interactivePrompterMu.Lock()
+	defer interactivePrompterMu.Unlock()
+	interactivePrompter = prompter
+}
+
+// keyboardInteractiveAuth 生成 keyboard-interactive 认证方法，质询交给用户回答，
+// interactive 为 false 时只自动填写保存的密码
+func keyboardInteractiveAuth(config *models.SSHConfig, interactive bool) ssh.AuthMethod {
+	passwordUsed := false
+
+	return ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		// 没有问题的质询只用于展示信息，直接应答
+		if len(questions) == 0 {
+			return []string{}, nil
+		}
+
+		// 单个密码问题且配置中保存了密码时自动填写（只尝试一次，避免密码错误时循环）
+		if !passwordUsed && config.Password != "" && len(questions) == 1 && !echos[0] &&
+			strings.Contains(strings.ToLower(questions[0]), "password") {
+			passwordUsed = true
+			return []string{config.Password}, nil
+		}
+
+		if !interactive {
+			return nil, fmt.Errorf("%w: 服务器要求回答交互式认证质询", ErrInteractionRequired)
+		}
+
+		interactivePrompterMu.RLock()
+		prompter := interactivePrompter
+		interactivePrompterMu.RUnlock()
+
+		if prompter == nil {
+			return nil, fmt.Errorf("服务器要求交互式认证，但无法向用户提问")
+		}
+
+		answers, err := prompter(KeyboardInteractivePrompt{
+			ConfigName:  config.Name,
+			Host:        config.Host,
+			Name:        name,
+			Instruction: instruction,
+			Questions:   questions,
+			Echos:       echos,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("交互式认证失败: %w", err)
+		}
+		if len(answers) != len(questions) {
+			return nil, fmt.Errorf("交互式认证已取消")
+		}
+
+		return answers, nil
+	})
+}
@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// JumpHostError 跳板机链中某一跳连接失败
+type JumpHostError struct {
+	Hop   int    // 从 1 开始的跳数
+	Total int    // 总跳数（含目标主机）
+	Addr  string // 该跳的地址
+	Err   error
+}
+
+func (e *JumpHostError) Error() string {
+	if e.Hop == e.Total {
+		return fmt.Sprintf("经跳板机连接目标主机 %s 失败: %v", e.Addr, e.Err)
+	}
+	return fmt.Sprintf("第 %d/%d 跳 %s 连接失败: %v", e.Hop, e.Total, e.Addr, e.Err)
+}
+
+func (e *JumpHostError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	configResolver   func(id string) (*models.SSHConfig, error)
+	configResolverMu sync.RWMutex
+)
+
+// SetConfigResolver 设置按 ID 查找已保存配置的方法，用于解析引用其他配置的跳板机
+func SetConfigResolver(resolver func(id string) (*models.SSHConfig, error)) {
+	configResolverMu.Lock()
+	defer configResolverMu.Unlock()
+	configResolver = resolver
+}
+
+// resolveHops 将配置展开为完整的连接链，最后一项为目标主机
+func resolveHops(config *models.SSHConfig) ([]*models.SSHConfig, error) {
+	return resolveHopsVisited(config, map[string]bool{})
+}
+
+func resolveHopsVisited(config *models.SSHConfig, visited map[string]bool) ([]*models.SSHConfig, error) {
+	if config.ID != "" {
+		if visited[config.ID] {
+			return nil, fmt.Errorf("跳板机配置存在循环引用: %s", config.Name)
+		}
+		visited[config.ID] = true
+		defer delete(visited, config.ID)
+	}
+
+	var hops []*models.SSHConfig
+	for i, jump := range config.JumpHosts {
+		if jump.ConfigID == "" {
+			hops = append(hops, jumpHostConfig(jump))
+			continue
+		}
+
+		configResolverMu.RLock()
+		resolver := configResolver
+		configResolverMu.RUnlock()
+		if resolver == nil {
+			return nil, fmt.Errorf("无法解析第 %d 个跳板机配置", i+1)
+		}
+
+		jumpConfig, err := resolver(jump.ConfigID)
+		if err != nil {
+			return nil, fmt.Errorf("获取第 %d 个跳板机配置失败: %w", i+1, err)
+		}
+
+		// 被引用的配置自身也可能经过跳板机
+		jumpHops, err := resolveHopsVisited(jumpConfig, visited)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, jumpHops...)
+	}
+
+	return append(hops, config), nil
+}
+
+// jumpHostConfig 将内联跳板机信息转换为连接配置
+func jumpHostConfig(jump models.JumpHost) *models.SSHConfig {
+	port := jump.Port
+	if port == "" {
+		port = "22"
+	}
+	return &models.SSHConfig{
+		Name:          jump.Host,
+		Host:          jump.Host,
+		Port:          port,
+		Username:      jump.Username,
+		Password:      jump.Password,
+		KeyPath:       jump.KeyPath,
+		KeyID:         jump.KeyID,
+		KeyPassphrase: jump.KeyPassphrase,
+		AuthType:      jump.AuthType,
+	}
+}
+
+// connectHops 依次连接每一跳，后一跳通过前一跳的连接建立
+func connectHops(hops []*models.SSHConfig, interactive bool) (*ssh.Client, error) {
+	var client *ssh.Client
+	for i, hop := range hops {
+		addr := net.JoinHostPort(hop.Host, hop.Port)
+
+		clientConfig, cleanup, err := newClientConfig(hop, interactive)
+		if err == nil {
+			var next *ssh.Client
+			next, err = dialThrough(client, addr, clientConfig)
+			cleanup()
+			if err == nil {
+				client = next
+				continue
+			}
+		}
+
+		if client != nil {
+			client.Close()
+		}
+		if len(hops) == 1 {
+			return nil, fmt.Errorf("SSH 连接失败: %w", err)
+		}
+		return nil, &JumpHostError{Hop: i + 1, Total: len(hops), Addr: addr, Err: err}
+	}
+
+	return client, nil
+}
+
+// dialThrough 通过上一跳的连接（为 nil 时直接连接）建立到 addr 的 SSH 连接
+func dialThrough(prev *ssh.Client, addr string, clientConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	if prev == nil {
+		return ssh.Dial("tcp", addr, clientConfig)
+	}
+
+	conn, err := prev.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("跳板机转发失败: %w", err)
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client := ssh.NewClient(c, chans, reqs)
+
+	// 目标连接关闭后一并关闭上一跳
+	go func() {
+		client.Wait()
+		prev.Close()
+	}()
+
+	return client, nil
+}
+
+// JumpHostSpec 生成 OpenSSH -J 参数使用的跳板机描述
+func JumpHostSpec(config *models.SSHConfig) (string, error) {
+	hops, err := resolveHops(config)
+	if err != nil {
+		return "", err
+	}
+
+	specs := make([]string, 0, len(hops)-1)
+	for _, hop := range hops[:len(hops)-1] {
+		spec := net.JoinHostPort(hop.Host, hop.Port)
+		// 未填写用户名时由 ssh 按本地配置或当前用户决定
+		if hop.Username != "" {
+			spec = hop.Username + "@" + spec
+		}
+		specs = append(specs, spec)
+	}
+	return strings.Join(specs, ","), nil
+}
@@ -0,0 +1,33 @@
+package ssh
+
+import (
+	"testing"
+
+	"ssh-mdzz/models"
+)
+
+func TestJumpHostSpec(t *testing.T) {
+	tests := []struct {
+		name  string
+		jumps []models.JumpHost
+		want  string
+	}{
+		{"没有跳板机", nil, ""},
+		{"带用户名", []models.JumpHost{{Host: "bastion", Port: "2200", Username: "admin"}}, "admin@bastion:2200"},
+		{"未填写用户名", []models.JumpHost{{Host: "bastion"}}, "bastion:22"},
+		{"IPv6 地址", []models.JumpHost{{Host: "::1", Username: "admin"}}, "admin@[::1]:22"},
+		{"多个跳板机", []models.JumpHost{{Host: "a", Username: "u"}, {Host: "b"}}, "u@a:22,b:22"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JumpHostSpec(&models.SSHConfig{Host: "target", Port: "22", JumpHosts: tt.jumps})
+			if err != nil {
+				t.Fatalf("JumpHostSpec: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("JumpHostSpec() = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package ssh
+
+import (
+	"fmt"
+	"time"
+
+	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// 保活默认参数，配置中未设置时使用
+const (
+	defaultKeepAliveInterval  = 30 * time.Second
+	defaultKeepAliveMaxMissed = 3
+)
+
+// keepAliveSettings 返回配置的保活间隔和允许连续丢失的次数，间隔为 0 表示关闭保活
+func keepAliveSettings(config *models.SSHConfig) (time.Duration, int) {
+	interval := defaultKeepAliveInterval
+	if config.KeepAliveInterval > 0 {
+		interval = time.Duration(config.KeepAliveInterval) * time.Second
+	} else if config.KeepAliveInterval < 0 {
+		interval = 0
+	}
+
+	maxMissed := defaultKeepAliveMaxMissed
+	if config.KeepAliveMaxMissed > 0 {
+		maxMissed = config.KeepAliveMaxMissed
+	}
+	return interval, maxMissed
+}
+
+// sendKeepAlive 发送 keepalive@openssh.com 全局请求并返回往返时间
+// 服务器不认识该请求时会回复失败，同样说明连接可用
+func sendKeepAlive(client *ssh.Client) (time.Duration, error) {
+	start := time.Now()
+	if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// keepAlive 在连接上定时发送保活请求，连续 maxMissed 次未在间隔内收到回复时断开连接，由重连协程恢复
+func (sm *SessionManager) keepAlive(session *Session, client *ssh.Client, done <-chan struct{}) {
+	interval, maxMissed := keepAliveSettings(session.Config)
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		// 回复超时前请求会一直阻塞，放到单独的协程中，连接关闭时随之返回
+		result := make(chan error, 1)
+		go func() {
+			rtt, err := sendKeepAlive(client)
+			if err == nil {
+				session.rtt.Store(int64(rtt))
+			}
+			result <- err
+		}()
+
+		var err error
+		select {
+		case <-done:
+			return
+		case err = <-result:
+		case <-time.After(interval):
+			err = fmt.Errorf("%s 内未收到回复", interval)
+		}
+
+		if err == nil {
+			missed = 0
+			continue
+		}
+
+		missed++
+		fmt.Printf("会话 %s 保活失败（%d/%d）: %v\n", session.Config.ID, missed, maxMissed, err)
+		if missed >= maxMissed {
+			client.Close()
+			return
+		}
+	}
+}
+
+// RTT 返回最近一次保活请求的往返时间，尚未测量时为 0
+func (s *Session) RTT() time.Duration {
+	return time.Duration(s.rtt.Load())
+}
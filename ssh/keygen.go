@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	"ssh-mdzz/models"
+)
+
+// GenerateKeyPair 生成密钥对，返回不带密码的 OpenSSH 格式私钥和公钥信息
+// keyType 为 ed25519、rsa 或 ecdsa；bits 对 rsa 为 3072/4096，对 ecdsa 为 256/384/521，为 0 时使用默认值
+func GenerateKeyPair(keyType string, bits int, comment string) ([]byte, *models.VaultKey, error) {
+	var rawKey interface{}
+
+	switch keyType {
+	case "ed25519", "":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		rawKey = key
+	case "rsa":
+		if bits == 0 {
+			bits = 3072
+		}
+		if bits != 3072 && bits != 4096 {
+			return nil, nil, fmt.Errorf("RSA 密钥长度仅支持 3072 或 4096")
+		}
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, nil, err
+		}
+		rawKey = key
+	case "ecdsa":
+		var curve elliptic.Curve
+		switch bits {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, nil, fmt.Errorf("ECDSA 密钥长度仅支持 256、384 或 521")
+		}
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		rawKey = key
+	default:
+		return nil, nil, fmt.Errorf("不支持的密钥类型: %s", keyType)
+	}
+
+	return encodeVaultKey(rawKey, comment)
+}
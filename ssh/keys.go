@@ -0,0 +1,160 @@
+package ssh
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// PassphrasePrompt 私钥需要密码时交给用户输入的信息
+type PassphrasePrompt struct {
+	KeyPath    string `json:"keyPath"`
+	ConfigName string `json:"configName"`
+	Retry      bool   `json:"retry"` // 上一次输入的密码错误
+}
+
+var (
+	passphrasePrompter func(PassphrasePrompt) (string, error)
+	// sessionPassphrases 用户临时输入的私钥密码，仅保存在内存中直到程序退出
+	sessionPassphrases = make(map[string]string)
+	passphraseMu       sync.Mutex
+)
+
+// SetPassphrasePrompter 设置私钥密码未保存时的输入回调
+func SetPassphrasePrompter(prompter func(PassphrasePrompt) (string, error)) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	passphrasePrompter = prompter
+}
+
+// loadKeySigner 读取私钥文件并解析，加密私钥依次使用已保存的密码、本次运行输入过的密码，
+// interactive 为 true 时最后提示用户输入
+func loadKeySigner(keyPath, passphrase, configName string, interactive bool) (ssh.Signer, error) {
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+
+	return parseKeySigner(key, keyPath, passphrase, configName, interactive)
+}
+
+// parseKeySigner 解析私钥内容，keyName 用于提示和缓存密码
+func parseKeySigner(key []byte, keyName, passphrase, configName string, interactive bool) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey(key)
+	if err == nil {
+		return signer, nil
+	}
+
+	var missingErr *ssh.PassphraseMissingError
+	if !errors.As(err, &missingErr) {
+		return nil, fmt.Errorf("解析密钥失败: %w", err)
+	}
+
+	// 使用配置中保存的密码
+	if passphrase != "" {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+		if err != nil {
+			return nil, passphraseError(err)
+		}
+		return signer, nil
+	}
+
+	// 本次运行中用户已输入过的密码；提示期间不持有锁，避免阻塞其他私钥的加载
+	passphraseMu.Lock()
+	cached, ok := sessionPassphrases[keyName]
+	prompter := passphrasePrompter
+	passphraseMu.Unlock()
+	if ok {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(key, []byte(cached))
+		if err == nil {
+			return signer, nil
+		}
+		passphraseMu.Lock()
+		if sessionPassphrases[keyName] == cached {
+			delete(sessionPassphrases, keyName)
+		}
+		passphraseMu.Unlock()
+	}
+
+	if !interactive {
+		return nil, fmt.Errorf("%w: 私钥 %s 需要输入密码", ErrInteractionRequired, keyName)
+	}
+	if prompter == nil {
+		return nil, fmt.Errorf("私钥已加密，请在配置中填写密钥密码")
+	}
+
+	retry := false
+	for attempt := 0; attempt < 3; attempt++ {
+		input, err := prompter(PassphrasePrompt{
+			KeyPath:    keyName,
+			ConfigName: configName,
+			Retry:      retry,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("获取密钥密码失败: %w", err)
+		}
+		if input == "" {
+			return nil, fmt.Errorf("已取消输入密钥密码")
+		}
+
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(key, []byte(input))
+		if err == nil {
+			passphraseMu.Lock()
+			sessionPassphrases[keyName] = input
+			passphraseMu.Unlock()
+			return signer, nil
+		}
+		if !errors.Is(err, x509.IncorrectPasswordError) {
+			return nil, passphraseError(err)
+		}
+		retry = true
+	}
+
+	return nil, fmt.Errorf("密钥密码错误次数过多")
+}
+
+// passphraseError 将带密码解析私钥的错误转换为友好提示
+func passphraseError(err error) error {
+	if errors.Is(err, x509.IncorrectPasswordError) {
+		return fmt.Errorf("密钥密码错误")
+	}
+	return fmt.Errorf("解析密钥失败: %w", err)
+}
+
+// PublicKeyFromFile 获取私钥对应的 authorized_keys 格式公钥，优先读取同名 .pub 文件
+func PublicKeyFromFile(keyPath, passphrase, configName string) (string, error) {
+	if data, err := os.ReadFile(keyPath + ".pub"); err == nil {
+		if _, _, _, _, err := ssh.ParseAuthorizedKey(data); err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+
+	signer, err := loadKeySigner(keyPath, passphrase, configName, true)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))), nil
+}
+
+// LoadAuthPublicKey 读取密钥认证使用的私钥（keyID 非空时取自密钥库），确认可以解析后返回其公钥
+func LoadAuthPublicKey(keyID, keyPath, passphrase, configName string) (ssh.PublicKey, error) {
+	var signer ssh.Signer
+	var err error
+	switch {
+	case keyID != "":
+		signer, err = loadVaultKeySigner(keyID, passphrase, configName, true)
+	case keyPath != "":
+		signer, err = loadKeySigner(keyPath, passphrase, configName, true)
+	default:
+		return nil, fmt.Errorf("未提供私钥")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signer.PublicKey(), nil
+}
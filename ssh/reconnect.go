@@ -0,0 +1,194 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// 会话连接状态
+const (
+	SessionConnected    = "connected"
+	SessionReconnecting = "reconnecting"
+	SessionFailed       = "failed"
+)
+
+// 重连退避参数
+const (
+	reconnectBaseDelay   = time.Second
+	reconnectMaxDelay    = time.Minute
+	reconnectMaxAttempts = 10
+)
+
+var (
+	sessionStateHandler   func(models.SessionState)
+	sessionStateHandlerMu sync.RWMutex
+)
+
+// SetSessionStateHandler 设置会话连接状态变化的通知回调
+func SetSessionStateHandler(handler func(models.SessionState)) {
+	sessionStateHandlerMu.Lock()
+	defer sessionStateHandlerMu.Unlock()
+	sessionStateHandler = handler
+}
+
+func emitSessionState(state models.SessionState) {
+	sessionStateHandlerMu.RLock()
+	handler := sessionStateHandler
+	sessionStateHandlerMu.RUnlock()
+
+	if handler != nil {
+		handler(state)
+	}
+}
+
+// reconnectDelay 第 attempt 次重连前的等待时间：指数退避，在上限的一半到上限之间随机
+func reconnectDelay(attempt int) time.Duration {
+	delay := reconnectBaseDelay << uint(attempt-1)
+	if delay > reconnectMaxDelay || delay <= 0 {
+		delay = reconnectMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// isCurrent 判断会话是否仍然有效（未被用户关闭），调用方需持有 sm.mu
+func (sm *SessionManager) isCurrent(session *Session) bool {
+	return sm.sessions[session.Config.ID] == session && session.IsActive()
+}
+
+// supervise 在连接上运行保活，等待连接断开，非主动关闭时自动重连
+func (sm *SessionManager) supervise(session *Session, client *ssh.Client) {
+	for {
+		done := make(chan struct{})
+		go sm.keepAlive(session, client, done)
+		err := client.Wait()
+		close(done)
+
+		sm.mu.Lock()
+		if !sm.isCurrent(session) || session.Client() != client {
+			sm.mu.Unlock()
+			return
+		}
+		session.setState(SessionReconnecting)
+		sm.mu.Unlock()
+
+		fmt.Printf("会话 %s 连接断开: %v，开始重连\n", session.Config.ID, err)
+
+		client = sm.reconnect(session)
+		if client == nil {
+			return
+		}
+	}
+}
+
+// reconnect 按退避策略重新建立连接，成功返回新连接，放弃或会话已关闭时返回 nil
+func (sm *SessionManager) reconnect(session *Session) *ssh.Client {
+	configID := session.Config.ID
+	var lastErr error
+	attempts := 0
+
+	for attempt := 1; attempt <= reconnectMaxAttempts; attempt++ {
+		attempts = attempt
+		delay := reconnectDelay(attempt)
+		state := models.SessionState{
+			ConfigID:    configID,
+			State:       SessionReconnecting,
+			Attempt:     attempt,
+			MaxAttempts: reconnectMaxAttempts,
+			RetryInMs:   delay.Milliseconds(),
+		}
+		if lastErr != nil {
+			state.Error = lastErr.Error()
+		}
+		emitSessionState(state)
+
+		// 等待期间会话被关闭时立即结束，不再占用协程到退避结束
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-session.done():
+			timer.Stop()
+			return nil
+		}
+
+		sm.mu.RLock()
+		current := sm.isCurrent(session)
+		sm.mu.RUnlock()
+		if !current {
+			return nil
+		}
+
+		// 后台重连不弹出主机密钥、私钥密码或交互式认证提示，需要用户参与时直接放弃
+		client, err := createSSHClient(session.Config, false)
+		if err != nil {
+			lastErr = err
+			fmt.Printf("会话 %s 第 %d 次重连失败: %v\n", configID, attempt, err)
+			if errors.Is(err, ErrInteractionRequired) {
+				break
+			}
+			continue
+		}
+
+		// 打开文件传输子系统需要网络往返，在锁外完成，只在替换连接时持有锁
+		sftpClient, scpClient, err := openTransfer(client, session.Config)
+		if err != nil {
+			client.Close()
+			lastErr = err
+			continue
+		}
+
+		sm.mu.Lock()
+		if !sm.isCurrent(session) {
+			sm.mu.Unlock()
+			closeTransfer(sftpClient, scpClient)
+			client.Close()
+			return nil
+		}
+		oldSFTP, oldSCP := session.SFTP(), session.SCP()
+		session.install(client, sftpClient, scpClient)
+		session.setState(SessionConnected)
+		sm.mu.Unlock()
+
+		// 关闭旧连接上残留的传输通道
+		closeTransfer(oldSFTP, oldSCP)
+
+		// 本地转发和 SOCKS 代理每次连接时获取当前会话，远程转发需要在新连接上重新监听
+		sm.restartRemoteForwards(session)
+
+		fmt.Printf("会话 %s 重连成功（第 %d 次）\n", configID, attempt)
+		emitSessionState(models.SessionState{ConfigID: configID, State: SessionConnected, Attempt: attempt})
+		return client
+	}
+
+	fmt.Printf("会话 %s 重连失败，已放弃: %v\n", configID, lastErr)
+	state := models.SessionState{ConfigID: configID, State: SessionFailed, Attempt: attempts, MaxAttempts: reconnectMaxAttempts}
+	if lastErr != nil {
+		state.Error = lastErr.Error()
+	}
+	emitSessionState(state)
+
+	var tunnels []*Tunnel
+	sm.mu.Lock()
+	if sm.isCurrent(session) {
+		tunnels, _ = sm.closeSessionNoLock(configID)
+	}
+	sm.mu.Unlock()
+	closeTunnels(tunnels)
+	return nil
+}
+
+// closeTransfer 关闭文件传输客户端，nil 的忽略
+func closeTransfer(sftpClient *SFTPClient, scpClient *SCPClient) {
+	if sftpClient != nil {
+		sftpClient.Close()
+	}
+	if scpClient != nil {
+		scpClient.Close()
+	}
+}
@@ -0,0 +1,254 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer 只接受密码认证的本地 SSH 服务器，可以断开当前所有连接模拟网络中断
+type testServer struct {
+	listener     net.Listener
+	config       *ssh.ServerConfig
+	hostKey      ssh.PublicKey
+	allowForward bool // 是否同意 tcpip-forward 请求（不实际监听）
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newTestServer(t *testing.T, password string) *testServer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) == password {
+				return nil, nil
+			}
+			return nil, errors.New("密码错误")
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &testServer{listener: listener, config: config, hostKey: signer.PublicKey()}
+	go server.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		server.dropAll()
+	})
+	return server
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		go func() {
+			_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			go s.handleRequests(reqs)
+			for newChannel := range chans {
+				newChannel.Reject(ssh.Prohibited, "not supported")
+			}
+		}()
+	}
+}
+
+func (s *testServer) handleRequests(reqs <-chan *ssh.Request) {
+	for req := range reqs {
+		if !req.WantReply {
+			continue
+		}
+		if s.allowForward && req.Type == "tcpip-forward" {
+			// 端口为 0 时客户端从响应中读取分配的端口
+			req.Reply(true, ssh.Marshal(struct{ Port uint32 }{Port: 40022}))
+			continue
+		}
+		req.Reply(false, nil)
+	}
+}
+
+// dropAll 关闭所有已建立的连接
+func (s *testServer) dropAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testServer) addr() (string, string) {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host, port
+}
+
+// useTempKnownHosts 让全局主机密钥管理器使用临时文件并信任测试服务器
+func useTempKnownHosts(t *testing.T, server *testServer) {
+	t.Helper()
+
+	manager := GetHostKeyManager()
+	prevPath, prevSystem := manager.filePath, manager.UseSystemKnownHosts()
+	manager.filePath = filepath.Join(t.TempDir(), "known_hosts")
+	manager.SetUseSystemKnownHosts(false)
+	t.Cleanup(func() {
+		manager.filePath = prevPath
+		manager.SetUseSystemKnownHosts(prevSystem)
+	})
+
+	host, port := server.addr()
+	if err := manager.addKey(net.JoinHostPort(host, port), server.hostKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconnectAfterDrop(t *testing.T) {
+	server := newTestServer(t, "secret")
+	useTempKnownHosts(t, server)
+
+	host, port := server.addr()
+	config := &models.SSHConfig{
+		ID:                "reconnect-test",
+		Name:              "reconnect-test",
+		Host:              host,
+		Port:              port,
+		Username:          "user",
+		Password:          "secret",
+		AuthType:          "password",
+		TransferMode:      "scp",
+		KeepAliveInterval: -1,
+	}
+
+	sm := &SessionManager{
+		sessions: make(map[string]*Session),
+		tunnels:  make(map[string]*Tunnel),
+	}
+	session, err := sm.CreateSession(config)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	defer sm.CloseAllSessions()
+
+	first := session.Client()
+
+	// 重连期间并发读取会话状态，由 -race 检查数据竞争
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			sm.GetActiveSessions()
+			sm.IsSessionActive(config.ID)
+			_ = session.State()
+		}
+	}()
+
+	server.dropAll()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for session.Client() == first || session.State() != SessionConnected {
+		if time.Now().After(deadline) {
+			close(stop)
+			readers.Wait()
+			t.Fatalf("会话未重连，状态 %q", session.State())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	close(stop)
+	readers.Wait()
+
+	if !sm.IsSessionActive(config.ID) {
+		t.Fatal("重连后会话应保持活跃")
+	}
+}
+
+func TestReconnectDoesNotPromptForUnknownHost(t *testing.T) {
+	server := newTestServer(t, "secret")
+
+	manager := GetHostKeyManager()
+	prevPath := manager.filePath
+	manager.filePath = filepath.Join(t.TempDir(), "known_hosts")
+	defer func() { manager.filePath = prevPath }()
+
+	host, port := server.addr()
+	config := &models.SSHConfig{
+		Host:     host,
+		Port:     port,
+		Username: "user",
+		Password: "secret",
+		AuthType: "password",
+	}
+
+	_, err := createSSHClient(config, false)
+	if err == nil {
+		t.Fatal("未知主机的非交互连接应失败")
+	}
+	if !errors.Is(err, ErrInteractionRequired) {
+		t.Fatalf("错误应为 ErrInteractionRequired，实际为 %v", err)
+	}
+}
+
+func TestReconnectStopsWhenSessionClosed(t *testing.T) {
+	config := &models.SSHConfig{ID: "closed-while-waiting", Host: "127.0.0.1", Port: "1"}
+	session := &Session{ID: config.ID, Config: config}
+	session.active.Store(true)
+	sm := &SessionManager{
+		sessions: map[string]*Session{config.ID: session},
+		tunnels:  make(map[string]*Tunnel),
+	}
+
+	result := make(chan *ssh.Client, 1)
+	go func() { result <- sm.reconnect(session) }()
+
+	// 第一次重连前至少等待 reconnectBaseDelay 的一半，关闭会话后应立即返回
+	time.Sleep(20 * time.Millisecond)
+	if err := sm.CloseSession(config.ID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case client := <-result:
+		if client != nil {
+			t.Fatal("会话关闭后不应返回新连接")
+		}
+	case <-time.After(reconnectBaseDelay / 4):
+		t.Fatal("会话关闭后重连仍在等待退避结束")
+	}
+}
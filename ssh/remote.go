@@ -0,0 +1,251 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrRemoteForwardDenied 服务器拒绝了 tcpip-forward 请求
+var ErrRemoteForwardDenied = errors.New("服务器拒绝远程监听")
+
+// ErrRemoteConnectionLost 远程监听所在的 SSH 连接已断开，重连后会在新连接上重新监听
+var ErrRemoteConnectionLost = errors.New("远程监听所在的连接已断开")
+
+// RFC 4254 7.1
+type tcpipForwardMsg struct {
+	Addr string
+	Port uint32
+}
+
+// RFC 4254 7.2
+type forwardedTCPIPMsg struct {
+	Addr       string
+	Port       uint32
+	OriginAddr string
+	OriginPort uint32
+}
+
+// remoteForwarder 一个 SSH 连接上的远程监听，按监听端口分发服务器打开的 forwarded-tcpip 通道
+type remoteForwarder struct {
+	client    *ssh.Client
+	mu        sync.Mutex
+	listeners map[uint32]*remoteListener
+	lost      bool // 连接已断开
+}
+
+var (
+	remoteForwarders   = make(map[*ssh.Client]*remoteForwarder)
+	remoteForwardersMu sync.Mutex
+)
+
+// remoteForwarderFor 返回连接上的远程监听分发器，首次调用时注册 forwarded-tcpip 通道处理
+func remoteForwarderFor(client *ssh.Client) (*remoteForwarder, error) {
+	remoteForwardersMu.Lock()
+	defer remoteForwardersMu.Unlock()
+
+	if f, ok := remoteForwarders[client]; ok {
+		return f, nil
+	}
+
+	channels := client.HandleChannelOpen("forwarded-tcpip")
+	if channels == nil {
+		return nil, fmt.Errorf("forwarded-tcpip 通道处理已被注册")
+	}
+
+	f := &remoteForwarder{client: client, listeners: make(map[uint32]*remoteListener)}
+	remoteForwarders[client] = f
+	go f.dispatch(channels)
+	return f, nil
+}
+
+// dispatch 把服务器打开的通道交给对应端口的监听，连接断开后关闭所有监听
+func (f *remoteForwarder) dispatch(channels <-chan ssh.NewChannel) {
+	for newChannel := range channels {
+		var msg forwardedTCPIPMsg
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &msg); err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, "invalid forwarded-tcpip payload")
+			continue
+		}
+
+		f.mu.Lock()
+		listener, ok := f.listeners[msg.Port]
+		f.mu.Unlock()
+		if !ok {
+			newChannel.Reject(ssh.Prohibited, "no forward for address")
+			continue
+		}
+		// 等待 Accept 时不阻塞其他端口的通道
+		go func() {
+			if !listener.deliver(newChannel, &msg) {
+				newChannel.Reject(ssh.Prohibited, "no forward for address")
+			}
+		}()
+	}
+
+	remoteForwardersMu.Lock()
+	delete(remoteForwarders, f.client)
+	remoteForwardersMu.Unlock()
+
+	f.mu.Lock()
+	listeners := f.listeners
+	f.listeners = make(map[uint32]*remoteListener)
+	f.lost = true
+	f.mu.Unlock()
+	for _, listener := range listeners {
+		listener.shutdown(ErrRemoteConnectionLost)
+	}
+}
+
+// listenRemote 请求服务器在 addr 上监听，服务器拒绝时返回 ErrRemoteForwardDenied
+func listenRemote(client *ssh.Client, addr string) (net.Listener, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("端口格式错误: %s", portStr)
+	}
+
+	f, err := remoteForwarderFor(client)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, resp, err := client.SendRequest("tcpip-forward", true, ssh.Marshal(&tcpipForwardMsg{Addr: host, Port: uint32(port)}))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRemoteForwardDenied
+	}
+
+	// 端口为 0 时由服务器分配，实际端口在响应中返回
+	if port == 0 {
+		var allocated struct{ Port uint32 }
+		if err := ssh.Unmarshal(resp, &allocated); err != nil {
+			return nil, fmt.Errorf("解析服务器分配的端口失败: %w", err)
+		}
+		port = uint64(allocated.Port)
+	}
+
+	listener := &remoteListener{
+		forwarder: f,
+		host:      host,
+		port:      uint32(port),
+		incoming:  make(chan net.Conn),
+		done:      make(chan struct{}),
+	}
+
+	f.mu.Lock()
+	if f.lost {
+		f.mu.Unlock()
+		return nil, ErrRemoteConnectionLost
+	}
+	f.listeners[listener.port] = listener
+	f.mu.Unlock()
+	return listener, nil
+}
+
+// remoteListener 服务器上的一个监听，Accept 返回经 SSH 连接转发过来的连接
+type remoteListener struct {
+	forwarder *remoteForwarder
+	host      string
+	port      uint32
+	incoming  chan net.Conn
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// deliver 接受通道并交给 Accept，监听已关闭时返回 false
+func (l *remoteListener) deliver(newChannel ssh.NewChannel, msg *forwardedTCPIPMsg) bool {
+	select {
+	case <-l.done:
+		return false
+	default:
+	}
+
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return true
+	}
+	go ssh.DiscardRequests(requests)
+
+	conn := &channelConn{
+		Channel: channel,
+		laddr:   &net.TCPAddr{IP: net.ParseIP(msg.Addr), Port: int(msg.Port)},
+		raddr:   &net.TCPAddr{IP: net.ParseIP(msg.OriginAddr), Port: int(msg.OriginPort)},
+	}
+	select {
+	case l.incoming <- conn:
+	case <-l.done:
+		conn.Close()
+	}
+	return true
+}
+
+// shutdown 结束 Accept，之后 Accept 返回 err
+func (l *remoteListener) shutdown(err error) {
+	l.once.Do(func() {
+		l.err = err
+		close(l.done)
+	})
+}
+
+// Accept 等待下一个转发过来的连接
+func (l *remoteListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.incoming:
+		return conn, nil
+	case <-l.done:
+		return nil, l.err
+	}
+}
+
+// Close 取消服务器上的监听
+func (l *remoteListener) Close() error {
+	l.forwarder.mu.Lock()
+	if l.forwarder.listeners[l.port] == l {
+		delete(l.forwarder.listeners, l.port)
+	}
+	l.forwarder.mu.Unlock()
+
+	l.shutdown(net.ErrClosed)
+	_, _, err := l.forwarder.client.SendRequest("cancel-tcpip-forward", true,
+		ssh.Marshal(&tcpipForwardMsg{Addr: l.host, Port: l.port}))
+	return err
+}
+
+// Addr 返回服务器上的监听地址
+func (l *remoteListener) Addr() net.Addr {
+	return &remoteAddr{net.JoinHostPort(l.host, strconv.FormatUint(uint64(l.port), 10))}
+}
+
+// remoteAddr 服务器上的监听地址，主机部分可能是主机名或空（所有地址）
+type remoteAddr struct{ addr string }
+
+func (a *remoteAddr) Network() string { return "tcp" }
+func (a *remoteAddr) String() string  { return a.addr }
+
+// channelConn 将 SSH 通道包装为 net.Conn
+type channelConn struct {
+	ssh.Channel
+	laddr, raddr net.Addr
+}
+
+func (c *channelConn) LocalAddr() net.Addr  { return c.laddr }
+func (c *channelConn) RemoteAddr() net.Addr { return c.raddr }
+
+func (c *channelConn) SetDeadline(t time.Time) error {
+	return errors.New("SSH 通道不支持超时设置")
+}
+func (c *channelConn) SetReadDeadline(t time.Time) error  { return c.SetDeadline(t) }
+func (c *channelConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
@@ -0,0 +1,86 @@
+package ssh
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// dialTestServer 以密码认证连接测试服务器
+func dialTestServer(t *testing.T, server *testServer) *ssh.Client {
+	t.Helper()
+
+	client, err := ssh.Dial("tcp", server.listener.Addr().String(), &ssh.ClientConfig{
+		User:            "user",
+		Auth:            []ssh.AuthMethod{ssh.Password("secret")},
+		HostKeyCallback: ssh.FixedHostKey(server.hostKey),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestListenRemoteDenied(t *testing.T) {
+	server := newTestServer(t, "secret")
+	client := dialTestServer(t, server)
+
+	if _, err := listenRemote(client, "127.0.0.1:8080"); !errors.Is(err, ErrRemoteForwardDenied) {
+		t.Fatalf("服务器拒绝时应返回 ErrRemoteForwardDenied，实际为 %v", err)
+	}
+}
+
+func TestRemoteListenerConnectionLost(t *testing.T) {
+	server := newTestServer(t, "secret")
+	server.allowForward = true
+	client := dialTestServer(t, server)
+
+	listener, err := listenRemote(client, "127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("listenRemote: %v", err)
+	}
+	if got := listener.Addr().String(); got != "127.0.0.1:8080" {
+		t.Errorf("Addr() = %s", got)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := listener.Accept()
+		result <- err
+	}()
+
+	server.dropAll()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrRemoteConnectionLost) {
+			t.Fatalf("连接断开后 Accept 应返回 ErrRemoteConnectionLost，实际为 %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("连接断开后 Accept 未返回")
+	}
+}
+
+func TestRemoteListenerClose(t *testing.T) {
+	server := newTestServer(t, "secret")
+	server.allowForward = true
+	client := dialTestServer(t, server)
+
+	listener, err := listenRemote(client, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listenRemote: %v", err)
+	}
+	if got := listener.Addr().String(); got != "127.0.0.1:40022" {
+		t.Errorf("Addr() = %s，应为服务器分配的端口", got)
+	}
+	listener.Close()
+
+	if _, err := listener.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("关闭后 Accept 应返回 net.ErrClosed，实际为 %v", err)
+	}
+}
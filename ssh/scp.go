@@ -19,16 +19,10 @@ type SCPClient struct {
 	config    *models.SSHConfig
 }
 
-// NewSCPClient 创建 SCP 客户端
-func NewSCPClient(config *models.SSHConfig) (*SCPClient, error) {
-	sshClient, err := CreateSSHClient(config)
-	if err != nil {
-		return nil, err
-	}
-
+// NewSCPClient 基于会话已有的 SSH 连接创建 SCP 客户端
+func NewSCPClient(sshClient *ssh.Client, config *models.SSHConfig) (*SCPClient, error) {
 	scpClient, err := scp.NewClientBySSH(sshClient)
 	if err != nil {
-		sshClient.Close()
 		return nil, fmt.Errorf("创建 SCP 客户端失败: %w", err)
 	}
 
@@ -39,12 +33,9 @@ func NewSCPClient(config *models.SSHConfig) (*SCPClient, error) {
 	}, nil
 }
 
-// Close 关闭连接
+// Close 关闭 SCP 客户端（底层 SSH 连接由会话负责关闭）
 func (c *SCPClient) Close() error {
 	c.scpClient.Close()
-	if c.sshClient != nil {
-		return c.sshClient.Close()
-	}
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"ssh-mdzz/models"
@@ -14,23 +15,42 @@ import (
 
 // SessionManager 会话管理器
 type SessionManager struct {
-	sessions map[string]*Session
-	mu       sync.RWMutex
+	sessions  map[string]*Session
+	mu        sync.RWMutex
+	tunnels   map[string]*Tunnel // 端口转发，按隧道 ID 索引
+	tunnelsMu sync.Mutex
 }
 
 // Session SSH 会话
 type Session struct {
-	ID         string
-	Config     *models.SSHConfig
-	SSHClient  *ssh.Client
-	SFTPClient *SFTPClient
-	SCPClient  *SCPClient
-	CreatedAt  time.Time
-	IsActive   bool
+	ID        string
+	Config    *models.SSHConfig
+	CreatedAt time.Time
+
+	// 由重连协程写入、接口调用读取，通过 IsActive、State 访问
+	active atomic.Bool
+	state  atomic.Value // string，connected 或 reconnecting
+
+	// 会话关闭时关闭，用于中断重连前的等待，通过 done 获取
+	doneCh    chan struct{}
+	doneOnce  sync.Once
+	closeOnce sync.Once
+
+	// 重连时会替换连接和文件传输客户端，通过 Client、SFTP、SCP 读取
+	sshClient  *ssh.Client
+	sftpClient *SFTPClient
+	scpClient  *SCPClient
+	clientMu   sync.RWMutex
+
+	agentForwarding atomic.Bool   // 连接上是否已注册 agent 转发
+	x11             *x11Forwarder // 连接上的 X11 转发，首次请求时创建
+	x11Mu           sync.Mutex
+	rtt             atomic.Int64 // 最近一次保活的往返时间（纳秒）
 }
 
 var globalSessionManager = &SessionManager{
 	sessions: make(map[string]*Session),
+	tunnels:  make(map[string]*Tunnel),
 }
 
 // GetSessionManager 获取全局会话管理器
@@ -40,15 +60,12 @@ func GetSessionManager() *SessionManager {
 
 // CreateSession 创建新会话
 func (sm *SessionManager) CreateSession(config *models.SSHConfig) (*Session, error) {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
 	// 检查是否已存在
-	if session, exists := sm.sessions[config.ID]; exists && session.IsActive {
+	if session, err := sm.GetSession(config.ID); err == nil {
 		return session, nil
 	}
 
-	// 创建 SSH 客户端
+	// 连接可能等待用户确认主机密钥、输入私钥密码等，不持有锁，避免阻塞其他会话操作
 	sshClient, err := CreateSSHClient(config)
 	if err != nil {
 		return nil, fmt.Errorf("创建 SSH 客户端失败: %w", err)
@@ -57,32 +74,140 @@ func (sm *SessionManager) CreateSession(config *models.SSHConfig) (*Session, err
 	session := &Session{
 		ID:        config.ID,
 		Config:    config,
-		SSHClient: sshClient,
 		CreatedAt: time.Now(),
-		IsActive:  true,
+	}
+	session.active.Store(true)
+	session.setState(SessionConnected)
+
+	if err := session.attach(sshClient); err != nil {
+		sshClient.Close()
+		return nil, err
+	}
+
+	sm.mu.Lock()
+	// 等待连接期间其他调用已建立同一配置的会话时使用已有会话
+	if existing, exists := sm.sessions[config.ID]; exists && existing.IsActive() {
+		sm.mu.Unlock()
+		session.Close()
+		return existing, nil
+	}
+	sm.sessions[config.ID] = session
+	sm.mu.Unlock()
+
+	// 远程转发需要等待服务器响应，在锁外启动；启动期间会话被关闭时转发会自行停止
+	sm.startAutoForwards(session)
+	sm.mu.RLock()
+	current := sm.isCurrent(session)
+	sm.mu.RUnlock()
+	if !current {
+		return nil, fmt.Errorf("会话已关闭")
+	}
+
+	go sm.supervise(session, sshClient)
+	emitSessionState(models.SessionState{ConfigID: config.ID, State: SessionConnected})
+	return session, nil
+}
+
+// attach 在 SSH 连接上建立文件传输客户端和 agent 转发，用于新建的会话
+func (s *Session) attach(sshClient *ssh.Client) error {
+	sftpClient, scpClient, err := openTransfer(sshClient, s.Config)
+	if err != nil {
+		return err
 	}
 
-	// 根据传输模式创建对应客户端
+	s.install(sshClient, sftpClient, scpClient)
+	return nil
+}
+
+// openTransfer 根据传输模式在连接上创建对应的文件传输客户端，会打开子系统等待服务器响应，
+// 调用方不应持有会话管理器的锁
+func openTransfer(sshClient *ssh.Client, config *models.SSHConfig) (*SFTPClient, *SCPClient, error) {
+	// 与终端、命令执行复用同一个 SSH 连接
 	if config.TransferMode == "scp" {
 		// 创建 SCP 客户端
-		scpClient, err := NewSCPClient(config)
+		scpClient, err := NewSCPClient(sshClient, config)
 		if err != nil {
-			sshClient.Close()
-			return nil, fmt.Errorf("创建 SCP 客户端失败: %w", err)
+			return nil, nil, fmt.Errorf("创建 SCP 客户端失败: %w", err)
 		}
-		session.SCPClient = scpClient
-	} else {
-		// 默认使用 SFTP
-		sftpClient, err := NewSFTPClient(config)
-		if err != nil {
-			sshClient.Close()
-			return nil, fmt.Errorf("创建 SFTP 客户端失败: %w", err)
+		return nil, scpClient, nil
+	}
+
+	// 默认使用 SFTP
+	sftpClient, err := NewSFTPClient(sshClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建 SFTP 客户端失败: %w", err)
+	}
+	return sftpClient, nil, nil
+}
+
+// install 换上新的连接和文件传输客户端，并在连接上注册 X11、agent 转发通道，
+// 只注册本地的通道处理，不进行网络往返
+func (s *Session) install(sshClient *ssh.Client, sftpClient *SFTPClient, scpClient *SCPClient) {
+	config := s.Config
+
+	// X11 通道处理绑定在连接上，之前请求过转发时在新连接上重新注册；
+	// 替换连接期间持有 x11Mu，避免并发的首次请求注册到旧连接上
+	s.x11Mu.Lock()
+	s.clientMu.Lock()
+	s.sshClient = sshClient
+	s.sftpClient = sftpClient
+	s.scpClient = scpClient
+	s.clientMu.Unlock()
+	if s.x11 != nil {
+		if err := s.x11.serve(sshClient); err != nil {
+			fmt.Printf("CreateSession: 重新注册 X11 转发失败: %v\n", err)
+			s.x11 = nil
 		}
-		session.SFTPClient = sftpClient
 	}
+	s.x11Mu.Unlock()
 
-	sm.sessions[config.ID] = session
-	return session, nil
+	// 开启 agent 转发时注册转发通道，终端会话按需请求
+	s.agentForwarding.Store(false)
+	if config.AgentForwarding {
+		if err := setupAgentForwarding(sshClient); err != nil {
+			fmt.Printf("CreateSession: %v\n", err)
+		} else {
+			s.agentForwarding.Store(true)
+		}
+	}
+
+	s.rtt.Store(0)
+}
+
+// IsActive 会话是否仍然有效（未被关闭）
+func (s *Session) IsActive() bool {
+	return s.active.Load()
+}
+
+// State 返回会话当前的连接状态
+func (s *Session) State() string {
+	state, _ := s.state.Load().(string)
+	return state
+}
+
+func (s *Session) setState(state string) {
+	s.state.Store(state)
+}
+
+// Client 返回会话当前的 SSH 连接
+func (s *Session) Client() *ssh.Client {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.sshClient
+}
+
+// SFTP 返回会话当前的 SFTP 客户端，SCP 模式下为 nil
+func (s *Session) SFTP() *SFTPClient {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.sftpClient
+}
+
+// SCP 返回会话当前的 SCP 客户端，SFTP 模式下为 nil
+func (s *Session) SCP() *SCPClient {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.scpClient
 }
 
 // GetSession 获取会话
@@ -91,7 +216,7 @@ func (sm *SessionManager) GetSession(configID string) (*Session, error) {
 	defer sm.mu.RUnlock()
 
 	session, exists := sm.sessions[configID]
-	if !exists || !session.IsActive {
+	if !exists || !session.IsActive() {
 		return nil, fmt.Errorf("会话不存在或已关闭")
 	}
 
@@ -113,51 +238,42 @@ func (sm *SessionManager) GetOrCreateSession(config *models.SSHConfig) (*Session
 // CloseSession 关闭会话
 func (sm *SessionManager) CloseSession(configID string) error {
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	tunnels, err := sm.closeSessionNoLock(configID)
+	sm.mu.Unlock()
+
+	closeTunnels(tunnels)
+	return err
+}
 
+// closeSessionNoLock 关闭会话（内部方法，不加锁），返回会话的端口转发，由调用方释放锁后关闭
+func (sm *SessionManager) closeSessionNoLock(configID string) ([]*Tunnel, error) {
 	session, exists := sm.sessions[configID]
 	if !exists {
-		return fmt.Errorf("会话不存在")
-	}
-
-	// 关闭客户端
-	if session.SFTPClient != nil {
-		if err := session.SFTPClient.Close(); err != nil {
-			// 记录错误但继续关闭其他资源
-			fmt.Printf("关闭 SFTP 客户端失败: %v\n", err)
-		}
-	}
-
-	if session.SCPClient != nil {
-		if err := session.SCPClient.Close(); err != nil {
-			fmt.Printf("关闭 SCP 客户端失败: %v\n", err)
-		}
-	}
-
-	if session.SSHClient != nil {
-		if err := session.SSHClient.Close(); err != nil {
-			fmt.Printf("关闭 SSH 客户端失败: %v\n", err)
-		}
+		return nil, fmt.Errorf("会话不存在")
 	}
 
-	session.IsActive = false
+	tunnels := sm.takeTunnels(configID)
+	session.Close()
 	delete(sm.sessions, configID)
 
-	return nil
+	return tunnels, nil
 }
 
 // CloseAllSessions 关闭所有会话
 func (sm *SessionManager) CloseAllSessions() error {
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
 	var lastErr error
+	var tunnels []*Tunnel
 	for configID := range sm.sessions {
-		if err := sm.CloseSession(configID); err != nil {
+		closed, err := sm.closeSessionNoLock(configID)
+		if err != nil {
 			lastErr = err
 		}
+		tunnels = append(tunnels, closed...)
 	}
+	sm.mu.Unlock()
 
+	closeTunnels(tunnels)
 	return lastErr
 }
 
@@ -168,14 +284,17 @@ func (sm *SessionManager) GetActiveSessions() []models.SSHSession {
 
 	var sessions []models.SSHSession
 	for _, session := range sm.sessions {
-		if session.IsActive {
+		if session.IsActive() {
 			sessions = append(sessions, models.SSHSession{
 				ID:          session.ID,
 				ConfigID:    session.Config.ID,
 				ConfigName:  session.Config.Name,
 				Host:        session.Config.Host,
 				ConnectedAt: session.CreatedAt,
-				IsActive:    session.IsActive,
+				IsActive:    session.IsActive(),
+				State:       session.State(),
+				RTTMs:       float64(session.RTT()) / float64(time.Millisecond),
+				Tunnels:     sm.ListTunnels(session.Config.ID),
 			})
 		}
 	}
@@ -190,7 +309,7 @@ func (sm *SessionManager) GetSessionCount() int {
 
 	count := 0
 	for _, session := range sm.sessions {
-		if session.IsActive {
+		if session.IsActive() {
 			count++
 		}
 	}
@@ -203,40 +322,22 @@ func (sm *SessionManager) IsSessionActive(configID string) bool {
 	defer sm.mu.RUnlock()
 
 	session, exists := sm.sessions[configID]
-	return exists && session.IsActive
+	return exists && session.IsActive()
 }
 
-// KeepAlive 保持会话活跃（发送心跳）
-func (sm *SessionManager) KeepAlive(configID string) error {
+// KeepAlive 发送一次协议层保活请求，返回往返时间
+func (sm *SessionManager) KeepAlive(configID string) (time.Duration, error) {
 	session, err := sm.GetSession(configID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	// 发送一个简单的命令作为心跳
-	_, err = ExecuteCommand(session.SSHClient, "echo 'keepalive'")
-	return err
-}
-
-// StartKeepAliveRoutine 启动会话保活协程
-func (sm *SessionManager) StartKeepAliveRoutine(configID string, interval time.Duration) {
-	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			if !sm.IsSessionActive(configID) {
-				return // 会话已关闭，退出协程
-			}
-
-			if err := sm.KeepAlive(configID); err != nil {
-				fmt.Printf("会话 %s 保活失败: %v\n", configID, err)
-				// 可以选择关闭失效的会话
-				sm.CloseSession(configID)
-				return
-			}
-		}
-	}()
+	rtt, err := sendKeepAlive(session.Client())
+	if err != nil {
+		return 0, err
+	}
+	session.rtt.Store(int64(rtt))
+	return rtt, nil
 }
 
 // GetSessionUptime 获取会话运行时长
@@ -249,38 +350,76 @@ func (sm *SessionManager) GetSessionUptime(configID string) (time.Duration, erro
 	return time.Since(session.CreatedAt), nil
 }
 
+// Close 关闭会话的所有通道和底层 SSH 连接
+func (s *Session) Close() {
+	s.clientMu.RLock()
+	sshClient, sftpClient, scpClient := s.sshClient, s.sftpClient, s.scpClient
+	s.clientMu.RUnlock()
+
+	// 先关闭复用在连接上的文件传输通道
+	if sftpClient != nil {
+		if err := sftpClient.Close(); err != nil {
+			// 记录错误但继续关闭其他资源
+			fmt.Printf("关闭 SFTP 客户端失败: %v\n", err)
+		}
+	}
+
+	if scpClient != nil {
+		if err := scpClient.Close(); err != nil {
+			fmt.Printf("关闭 SCP 客户端失败: %v\n", err)
+		}
+	}
+
+	// 关闭底层连接，终端等其余通道随之结束
+	if sshClient != nil {
+		if err := sshClient.Close(); err != nil {
+			fmt.Printf("关闭 SSH 客户端失败: %v\n", err)
+		}
+	}
+
+	s.active.Store(false)
+	done := s.done()
+	s.closeOnce.Do(func() { close(done) })
+}
+
+// done 返回会话关闭时关闭的通道
+func (s *Session) done() chan struct{} {
+	s.doneOnce.Do(func() { s.doneCh = make(chan struct{}) })
+	return s.doneCh
+}
+
 // ListFiles 列出目录文件（根据会话类型调用对应方法）
 func (s *Session) ListFiles(remotePath string) ([]models.FileInfo, error) {
-	if s.SFTPClient != nil {
-		return s.SFTPClient.ListFiles(remotePath)
+	if s.SFTP() != nil {
+		return s.SFTP().ListFiles(remotePath)
 	}
-	if s.SCPClient != nil {
-		return s.SCPClient.ListFiles(remotePath)
+	if s.SCP() != nil {
+		return s.SCP().ListFiles(remotePath)
 	}
 	return nil, fmt.Errorf("没有可用的文件传输客户端")
 }
 
 // UploadFile 上传文件
 func (s *Session) UploadFile(localPath, remotePath string, useSudo bool, progressCallback func(int64, int64)) error {
-	if s.SFTPClient != nil {
+	if s.SFTP() != nil {
 		// SFTP 不支持 sudo，需要先上传再用 SSH 命令移动
 		if useSudo {
 			tmpPath := "/tmp/" + filepath.Base(localPath)
-			if err := s.SFTPClient.UploadFile(localPath, tmpPath, progressCallback); err != nil {
+			if err := s.SFTP().UploadFile(localPath, tmpPath, progressCallback); err != nil {
 				return err
 			}
 			moveCmd := fmt.Sprintf("sudo mv %s %s", tmpPath, remotePath)
-			_, err := ExecuteSudoCommand(s.SSHClient, s.Config.Password, moveCmd)
+			_, err := ExecuteSudoCommand(s.Client(), s.Config.Password, moveCmd)
 			return err
 		}
-		return s.SFTPClient.UploadFile(localPath, remotePath, progressCallback)
+		return s.SFTP().UploadFile(localPath, remotePath, progressCallback)
 	}
 
-	if s.SCPClient != nil {
+	if s.SCP() != nil {
 		if useSudo {
-			return s.SCPClient.UploadFileWithSudo(localPath, remotePath, progressCallback)
+			return s.SCP().UploadFileWithSudo(localPath, remotePath, progressCallback)
 		}
-		return s.SCPClient.UploadFile(localPath, remotePath, progressCallback)
+		return s.SCP().UploadFile(localPath, remotePath, progressCallback)
 	}
 
 	return fmt.Errorf("没有可用的文件传输客户端")
@@ -288,28 +427,28 @@ func (s *Session) UploadFile(localPath, remotePath string, useSudo bool, progres
 
 // DownloadFile 下载文件
 func (s *Session) DownloadFile(remotePath, localPath string, useSudo bool, progressCallback func(int64, int64)) error {
-	if s.SFTPClient != nil {
+	if s.SFTP() != nil {
 		// SFTP 需要 sudo 时，先用 SSH 命令复制到临时目录
 		if useSudo {
 			tmpPath := "/tmp/" + filepath.Base(remotePath)
 			copyCmd := fmt.Sprintf("sudo cp %s %s && sudo chmod 644 %s", remotePath, tmpPath, tmpPath)
-			if _, err := ExecuteSudoCommand(s.SSHClient, s.Config.Password, copyCmd); err != nil {
+			if _, err := ExecuteSudoCommand(s.Client(), s.Config.Password, copyCmd); err != nil {
 				return err
 			}
 			defer func() {
 				cleanCmd := fmt.Sprintf("rm -f %s", tmpPath)
-				ExecuteCommand(s.SSHClient, cleanCmd)
+				ExecuteCommand(s.Client(), cleanCmd)
 			}()
-			return s.SFTPClient.DownloadFile(tmpPath, localPath, progressCallback)
+			return s.SFTP().DownloadFile(tmpPath, localPath, progressCallback)
 		}
-		return s.SFTPClient.DownloadFile(remotePath, localPath, progressCallback)
+		return s.SFTP().DownloadFile(remotePath, localPath, progressCallback)
 	}
 
-	if s.SCPClient != nil {
+	if s.SCP() != nil {
 		if useSudo {
-			return s.SCPClient.DownloadFileWithSudo(remotePath, localPath, progressCallback)
+			return s.SCP().DownloadFileWithSudo(remotePath, localPath, progressCallback)
 		}
-		return s.SCPClient.DownloadFile(remotePath, localPath, progressCallback)
+		return s.SCP().DownloadFile(remotePath, localPath, progressCallback)
 	}
 
 	return fmt.Errorf("没有可用的文件传输客户端")
@@ -319,18 +458,18 @@ func (s *Session) DownloadFile(remotePath, localPath string, useSudo bool, progr
 func (s *Session) CreateDirectory(remotePath string, useSudo bool) error {
 	fmt.Printf("Session.CreateDirectory: 创建目录 %s (sudo: %v)\n", remotePath, useSudo)
 	
-	if s.SFTPClient != nil {
+	if s.SFTP() != nil {
 		if useSudo {
 			// SFTP 不直接支持 sudo，需要通过 SSH 命令
 			escapedPath := escapeShellPath(remotePath)
 			cmd := fmt.Sprintf("sudo mkdir -p %s", escapedPath)
 			fmt.Printf("Session.CreateDirectory: 执行 sudo 命令: %s\n", cmd)
-			_, err := ExecuteCommand(s.SSHClient, cmd)
+			_, err := ExecuteCommand(s.Client(), cmd)
 			return err
 		}
-		return s.SFTPClient.CreateDirectory(remotePath)
+		return s.SFTP().CreateDirectory(remotePath)
 	}
-	if s.SCPClient != nil {
+	if s.SCP() != nil {
 		// SCP 需要通过 SSH 命令创建目录
 		escapedPath := escapeShellPath(remotePath)
 		var cmd string
@@ -340,7 +479,7 @@ func (s *Session) CreateDirectory(remotePath string, useSudo bool) error {
 			cmd = fmt.Sprintf("mkdir -p %s", escapedPath)
 		}
 		fmt.Printf("Session.CreateDirectory: 执行命令: %s\n", cmd)
-		_, err := ExecuteCommand(s.SSHClient, cmd)
+		_, err := ExecuteCommand(s.Client(), cmd)
 		return err
 	}
 	return fmt.Errorf("没有可用的文件传输客户端")
@@ -350,13 +489,13 @@ func (s *Session) CreateDirectory(remotePath string, useSudo bool) error {
 func (s *Session) DeleteFile(remotePath string, useSudo bool) error {
 	fmt.Printf("Session.DeleteFile: 删除文件 %s (sudo: %v)\n", remotePath, useSudo)
 	
-	if s.SFTPClient != nil {
+	if s.SFTP() != nil {
 		if useSudo {
 			// SFTP 不直接支持 sudo，需要通过 SSH 命令
 			escapedPath := escapeShellPath(remotePath)
 			cmd := fmt.Sprintf("sudo rm -f %s", escapedPath)
 			fmt.Printf("Session.DeleteFile: 执行 sudo 命令: %s\n", cmd)
-			_, err := ExecuteCommand(s.SSHClient, cmd)
+			_, err := ExecuteCommand(s.Client(), cmd)
 			if err != nil {
 				fmt.Printf("Session.DeleteFile: 命令执行失败: %v\n", err)
 				return fmt.Errorf("删除文件失败: %v", err)
@@ -364,9 +503,9 @@ func (s *Session) DeleteFile(remotePath string, useSudo bool) error {
 			fmt.Printf("Session.DeleteFile: 文件删除成功\n")
 			return nil
 		}
-		return s.SFTPClient.DeleteFile(remotePath)
+		return s.SFTP().DeleteFile(remotePath)
 	}
-	if s.SCPClient != nil {
+	if s.SCP() != nil {
 		// 转义路径中的特殊字符
 		escapedPath := escapeShellPath(remotePath)
 		var cmd string
@@ -377,7 +516,7 @@ func (s *Session) DeleteFile(remotePath string, useSudo bool) error {
 		}
 		fmt.Printf("Session.DeleteFile: 执行命令: %s\n", cmd)
 		
-		_, err := ExecuteCommand(s.SSHClient, cmd)
+		_, err := ExecuteCommand(s.Client(), cmd)
 		if err != nil {
 			fmt.Printf("Session.DeleteFile: 命令执行失败: %v\n", err)
 			return fmt.Errorf("删除文件失败: %v", err)
@@ -393,13 +532,13 @@ func (s *Session) DeleteFile(remotePath string, useSudo bool) error {
 func (s *Session) DeleteDirectory(remotePath string, useSudo bool) error {
 	fmt.Printf("Session.DeleteDirectory: 删除目录 %s (sudo: %v)\n", remotePath, useSudo)
 	
-	if s.SFTPClient != nil {
+	if s.SFTP() != nil {
 		if useSudo {
 			// SFTP 不直接支持 sudo，需要通过 SSH 命令
 			escapedPath := escapeShellPath(remotePath)
 			cmd := fmt.Sprintf("sudo rm -rf %s", escapedPath)
 			fmt.Printf("Session.DeleteDirectory: 执行 sudo 命令: %s\n", cmd)
-			_, err := ExecuteCommand(s.SSHClient, cmd)
+			_, err := ExecuteCommand(s.Client(), cmd)
 			if err != nil {
 				fmt.Printf("Session.DeleteDirectory: 命令执行失败: %v\n", err)
 				return fmt.Errorf("删除目录失败: %v", err)
@@ -407,9 +546,9 @@ func (s *Session) DeleteDirectory(remotePath string, useSudo bool) error {
 			fmt.Printf("Session.DeleteDirectory: 目录删除成功\n")
 			return nil
 		}
-		return s.SFTPClient.DeleteDirectory(remotePath)
+		return s.SFTP().DeleteDirectory(remotePath)
 	}
-	if s.SCPClient != nil {
+	if s.SCP() != nil {
 		// 转义路径中的特殊字符
 		escapedPath := escapeShellPath(remotePath)
 		var cmd string
@@ -420,7 +559,7 @@ func (s *Session) DeleteDirectory(remotePath string, useSudo bool) error {
 		}
 		fmt.Printf("Session.DeleteDirectory: 执行命令: %s\n", cmd)
 		
-		_, err := ExecuteCommand(s.SSHClient, cmd)
+		_, err := ExecuteCommand(s.Client(), cmd)
 		if err != nil {
 			fmt.Printf("Session.DeleteDirectory: 命令执行失败: %v\n", err)
 			return fmt.Errorf("删除目录失败: %v", err)
@@ -434,10 +573,10 @@ func (s *Session) DeleteDirectory(remotePath string, useSudo bool) error {
 
 // GetTransferMode 获取传输模式
 func (s *Session) GetTransferMode() string {
-	if s.SFTPClient != nil {
+	if s.SFTP() != nil {
 		return "sftp"
 	}
-	if s.SCPClient != nil {
+	if s.SCP() != nil {
 		return "scp"
 	}
 	return "unknown"
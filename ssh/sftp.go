@@ -17,16 +17,10 @@ type SFTPClient struct {
 	sftpClient *sftp.Client
 }
 
-// NewSFTPClient 创建 SFTP 客户端
-func NewSFTPClient(config *models.SSHConfig) (*SFTPClient, error) {
-	sshClient, err := CreateSSHClient(config)
-	if err != nil {
-		return nil, err
-	}
-
+// NewSFTPClient 基于会话已有的 SSH 连接创建 SFTP 客户端
+func NewSFTPClient(sshClient *ssh.Client) (*SFTPClient, error) {
 	sftpClient, err := sftp.NewClient(sshClient)
 	if err != nil {
-		sshClient.Close()
 		return nil, fmt.Errorf("创建 SFTP 客户端失败: %w", err)
 	}
 
@@ -36,13 +30,10 @@ func NewSFTPClient(config *models.SSHConfig) (*SFTPClient, error) {
 	}, nil
 }
 
-// Close 关闭连接
+// Close 关闭 SFTP 通道（底层 SSH 连接由会话负责关闭）
 func (c *SFTPClient) Close() error {
 	if c.sftpClient != nil {
-		c.sftpClient.Close()
-	}
-	if c.sshClient != nil {
-		return c.sshClient.Close()
+		return c.sftpClient.Close()
 	}
 	return nil
 }
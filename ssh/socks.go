@@ -0,0 +1,229 @@
+package ssh
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SOCKS5 协议常量（RFC 1928、RFC 1929）
+const (
+	socksVersion      = 0x05
+	socksAuthVersion  = 0x01
+	socksMethodNone   = 0x00
+	socksMethodPasswd = 0x02
+	socksNoAcceptable = 0xff
+	socksCmdConnect   = 0x01
+	socksAtypIPv4     = 0x01
+	socksAtypDomain   = 0x03
+	socksAtypIPv6     = 0x04
+
+	socksSucceeded           = 0x00
+	socksGeneralFailure      = 0x01
+	socksNotAllowed          = 0x02
+	socksNetworkUnreachable  = 0x03
+	socksHostUnreachable     = 0x04
+	socksConnectionRefused   = 0x05
+	socksCommandNotSupported = 0x07
+	socksAddrNotSupported    = 0x08
+)
+
+// socksHandshakeTimeout 客户端完成 SOCKS 握手的最长时间
+const socksHandshakeTimeout = 30 * time.Second
+
+// socksReplyError 握手失败时需要回复客户端的错误码
+type socksReplyError struct {
+	code byte
+	err  error
+}
+
+func (e *socksReplyError) Error() string { return e.err.Error() }
+
+// socksHandshake 完成 SOCKS5 认证并读取 CONNECT 请求，返回目标地址
+func socksHandshake(conn net.Conn, username, password string) (string, error) {
+	// 问候：VER NMETHODS METHODS
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != socksVersion {
+		return "", fmt.Errorf("不支持的 SOCKS 版本: %d", header[0])
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+
+	method := byte(socksMethodNone)
+	if username != "" || password != "" {
+		method = socksMethodPasswd
+	}
+	offered := false
+	for _, m := range methods {
+		if m == method {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		conn.Write([]byte{socksVersion, socksNoAcceptable})
+		return "", errors.New("客户端不支持所需的认证方式")
+	}
+	if _, err := conn.Write([]byte{socksVersion, method}); err != nil {
+		return "", err
+	}
+
+	if method == socksMethodPasswd {
+		if err := socksAuthenticate(conn, username, password); err != nil {
+			return "", err
+		}
+	}
+
+	// 请求：VER CMD RSV ATYP DST.ADDR DST.PORT
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[0] != socksVersion {
+		return "", fmt.Errorf("不支持的 SOCKS 版本: %d", request[0])
+	}
+
+	var host string
+	switch request[3] {
+	case socksAtypIPv4, socksAtypIPv6:
+		size := net.IPv4len
+		if request[3] == socksAtypIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case socksAtypDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", err
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", &socksReplyError{socksAddrNotSupported, fmt.Errorf("不支持的地址类型: %d", request[3])}
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+
+	if request[1] != socksCmdConnect {
+		return "", &socksReplyError{socksCommandNotSupported, fmt.Errorf("不支持的 SOCKS 命令: %d", request[1])}
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// socksAuthenticate 用户名密码认证（RFC 1929）
+func socksAuthenticate(conn net.Conn, username, password string) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[0] != socksAuthVersion {
+		return fmt.Errorf("不支持的认证版本: %d", header[0])
+	}
+	user := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, user); err != nil {
+		return err
+	}
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return err
+	}
+	pass := make([]byte, length[0])
+	if _, err := io.ReadFull(conn, pass); err != nil {
+		return err
+	}
+
+	userOK := subtle.ConstantTimeCompare(user, []byte(username)) == 1
+	passOK := subtle.ConstantTimeCompare(pass, []byte(password)) == 1
+	if !userOK || !passOK {
+		conn.Write([]byte{socksAuthVersion, 0x01})
+		return errors.New("SOCKS 用户名或密码错误")
+	}
+
+	_, err := conn.Write([]byte{socksAuthVersion, 0x00})
+	return err
+}
+
+// socksReply 回复 CONNECT 请求结果，绑定地址固定为 0.0.0.0:0
+func socksReply(conn net.Conn, code byte) error {
+	_, err := conn.Write([]byte{socksVersion, code, 0x00, socksAtypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+// socksDialReplyCode 将通过 SSH 连接目标的错误映射为 SOCKS 回复码
+// 服务器拒绝 direct-tcpip 通道时按拒绝原因和描述区分，其余错误（如会话断开）回复一般错误
+func socksDialReplyCode(err error) byte {
+	var openErr *ssh.OpenChannelError
+	if !errors.As(err, &openErr) {
+		return socksGeneralFailure
+	}
+
+	switch openErr.Reason {
+	case ssh.Prohibited:
+		return socksNotAllowed
+	case ssh.ConnectionFailed:
+		message := strings.ToLower(openErr.Message)
+		switch {
+		case strings.Contains(message, "refused"):
+			return socksConnectionRefused
+		case strings.Contains(message, "network is unreachable"):
+			return socksNetworkUnreachable
+		default:
+			// 连接超时、无路由、域名无法解析等
+			return socksHostUnreachable
+		}
+	default:
+		return socksGeneralFailure
+	}
+}
+
+// socksConnect 完成 SOCKS 握手并通过 dial 连接目标，返回目标连接
+func socksConnect(conn net.Conn, username, password string, dial func(addr string) (net.Conn, error)) (net.Conn, error) {
+	conn.SetDeadline(time.Now().Add(socksHandshakeTimeout))
+
+	target, err := socksHandshake(conn, username, password)
+	if err != nil {
+		var replyErr *socksReplyError
+		if errors.As(err, &replyErr) {
+			socksReply(conn, replyErr.code)
+		}
+		return nil, err
+	}
+
+	remote, err := dial(target)
+	if err != nil {
+		socksReply(conn, socksDialReplyCode(err))
+		return nil, fmt.Errorf("连接 %s 失败: %w", target, err)
+	}
+
+	if err := socksReply(conn, socksSucceeded); err != nil {
+		remote.Close()
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{})
+	return remote, nil
+}
@@ -0,0 +1,202 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// socksTestConns 返回一对本地 TCP 连接，内核缓冲避免双方同时写入时阻塞
+func socksTestConns(t *testing.T) (client, server net.Conn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+	client, err = net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = <-accepted
+	if server == nil {
+		t.Fatal("接受连接失败")
+	}
+	return client, server
+}
+
+func TestSocksConnect(t *testing.T) {
+	greetNone := []byte{socksVersion, 1, socksMethodNone}
+	greetPasswd := []byte{socksVersion, 2, socksMethodNone, socksMethodPasswd}
+	connectIPv4 := []byte{socksVersion, socksCmdConnect, 0, socksAtypIPv4, 10, 0, 0, 1, 0, 80}
+	auth := func(user, pass string) []byte {
+		b := []byte{socksAuthVersion, byte(len(user))}
+		b = append(b, user...)
+		b = append(b, byte(len(pass)))
+		return append(b, pass...)
+	}
+	reply := func(code byte) []byte {
+		return []byte{socksVersion, code, 0, socksAtypIPv4, 0, 0, 0, 0, 0, 0}
+	}
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	refused := &ssh.OpenChannelError{Reason: ssh.ConnectionFailed, Message: "Connection refused"}
+
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		input      []byte
+		dialErr    error
+		wantTarget string // 为空表示不应发起连接
+		wantOutput []byte
+		wantErr    bool
+	}{
+		{
+			name:       "无认证 IPv4",
+			input:      join(greetNone, connectIPv4),
+			wantTarget: "10.0.0.1:80",
+			wantOutput: join([]byte{socksVersion, socksMethodNone}, reply(socksSucceeded)),
+		},
+		{
+			name:       "域名",
+			input:      join(greetNone, []byte{socksVersion, socksCmdConnect, 0, socksAtypDomain, 11}, []byte("example.com"), []byte{0x01, 0xbb}),
+			wantTarget: "example.com:443",
+			wantOutput: join([]byte{socksVersion, socksMethodNone}, reply(socksSucceeded)),
+		},
+		{
+			name:       "IPv6",
+			input:      join(greetNone, []byte{socksVersion, socksCmdConnect, 0, socksAtypIPv6}, net.IPv6loopback, []byte{0, 22}),
+			wantTarget: "[::1]:22",
+			wantOutput: join([]byte{socksVersion, socksMethodNone}, reply(socksSucceeded)),
+		},
+		{
+			name:       "用户名密码认证",
+			username:   "user",
+			password:   "pass",
+			input:      join(greetPasswd, auth("user", "pass"), connectIPv4),
+			wantTarget: "10.0.0.1:80",
+			wantOutput: join([]byte{socksVersion, socksMethodPasswd}, []byte{socksAuthVersion, 0}, reply(socksSucceeded)),
+		},
+		{
+			name:       "密码错误",
+			username:   "user",
+			password:   "pass",
+			input:      join(greetPasswd, auth("user", "wrong"), connectIPv4),
+			wantOutput: []byte{socksVersion, socksMethodPasswd, socksAuthVersion, 1},
+			wantErr:    true,
+		},
+		{
+			name:       "客户端不支持密码认证",
+			username:   "user",
+			password:   "pass",
+			input:      join(greetNone, connectIPv4),
+			wantOutput: []byte{socksVersion, socksNoAcceptable},
+			wantErr:    true,
+		},
+		{
+			name:       "不支持的版本",
+			input:      []byte{0x04, 1, socksMethodNone},
+			wantOutput: []byte{},
+			wantErr:    true,
+		},
+		{
+			name:       "不支持的命令",
+			input:      join(greetNone, []byte{socksVersion, 0x02, 0, socksAtypIPv4, 10, 0, 0, 1, 0, 80}),
+			wantOutput: join([]byte{socksVersion, socksMethodNone}, reply(socksCommandNotSupported)),
+			wantErr:    true,
+		},
+		{
+			name:       "不支持的地址类型",
+			input:      join(greetNone, []byte{socksVersion, socksCmdConnect, 0, 0x05}),
+			wantOutput: join([]byte{socksVersion, socksMethodNone}, reply(socksAddrNotSupported)),
+			wantErr:    true,
+		},
+		{
+			name:       "目标拒绝连接",
+			input:      join(greetNone, connectIPv4),
+			dialErr:    refused,
+			wantTarget: "10.0.0.1:80",
+			wantOutput: join([]byte{socksVersion, socksMethodNone}, reply(socksConnectionRefused)),
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := socksTestConns(t)
+			defer client.Close()
+
+			var dialed string
+			done := make(chan error, 1)
+			go func() {
+				defer server.Close()
+				remote, err := socksConnect(server, tt.username, tt.password, func(addr string) (net.Conn, error) {
+					dialed = addr
+					if tt.dialErr != nil {
+						return nil, tt.dialErr
+					}
+					local, peer := net.Pipe()
+					peer.Close()
+					return local, nil
+				})
+				if remote != nil {
+					remote.Close()
+				}
+				done <- err
+			}()
+
+			if _, err := client.Write(tt.input); err != nil {
+				t.Fatal(err)
+			}
+			client.(*net.TCPConn).CloseWrite()
+			output, _ := io.ReadAll(client)
+			err := <-done
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("socksConnect() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if dialed != tt.wantTarget {
+				t.Fatalf("连接的目标为 %q，期望 %q", dialed, tt.wantTarget)
+			}
+			if !bytes.Equal(output, tt.wantOutput) {
+				t.Fatalf("回复为 %v，期望 %v", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestSocksDialReplyCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want byte
+	}{
+		{"服务器禁止", &ssh.OpenChannelError{Reason: ssh.Prohibited, Message: "administratively prohibited"}, socksNotAllowed},
+		{"拒绝连接", &ssh.OpenChannelError{Reason: ssh.ConnectionFailed, Message: "Connection refused"}, socksConnectionRefused},
+		{"网络不可达", &ssh.OpenChannelError{Reason: ssh.ConnectionFailed, Message: "connect failed: Network is unreachable"}, socksNetworkUnreachable},
+		{"连接超时", &ssh.OpenChannelError{Reason: ssh.ConnectionFailed, Message: "connect failed: Connection timed out"}, socksHostUnreachable},
+		{"无路由", &ssh.OpenChannelError{Reason: ssh.ConnectionFailed, Message: "No route to host"}, socksHostUnreachable},
+		{"域名无法解析", &ssh.OpenChannelError{Reason: ssh.ConnectionFailed, Message: "getaddrinfo: Name or service not known"}, socksHostUnreachable},
+		{"资源不足", &ssh.OpenChannelError{Reason: ssh.ResourceShortage}, socksGeneralFailure},
+		{"会话断开", errors.New("ssh: unexpected packet"), socksGeneralFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := socksDialReplyCode(tt.err); got != tt.want {
+				t.Fatalf("socksDialReplyCode() = %#x，期望 %#x", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,538 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ssh-mdzz/models"
+)
+
+// 端口转发类型
+const (
+	ForwardLocal   = "local"
+	ForwardRemote  = "remote"
+	ForwardDynamic = "dynamic"
+)
+
+// tunnelStatsInterval 转发流量有变化时推送统计的间隔
+const tunnelStatsInterval = time.Second
+
+var (
+	tunnelEventHandler   func(models.TunnelInfo)
+	tunnelEventHandlerMu sync.RWMutex
+)
+
+// SetTunnelEventHandler 设置端口转发状态和流量统计的通知回调
+func SetTunnelEventHandler(handler func(models.TunnelInfo)) {
+	tunnelEventHandlerMu.Lock()
+	defer tunnelEventHandlerMu.Unlock()
+	tunnelEventHandler = handler
+}
+
+func emitTunnelEvent(info models.TunnelInfo) {
+	tunnelEventHandlerMu.RLock()
+	handler := tunnelEventHandler
+	tunnelEventHandlerMu.RUnlock()
+
+	if handler != nil {
+		handler(info)
+	}
+}
+
+// Tunnel 一个端口转发，监听端接受的连接交给 handle 转发
+type Tunnel struct {
+	ID         string
+	ConfigID   string
+	Type       string
+	ListenAddr string
+	TargetAddr string
+	StartedAt  time.Time
+
+	listener net.Listener
+	handle   func(conn net.Conn) (net.Conn, error) // 为接受的连接建立到目标的连接
+
+	active        atomic.Int64
+	total         atomic.Int64
+	bytesSent     atomic.Int64
+	bytesReceived atomic.Int64
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	done   chan struct{}
+}
+
+// Info 返回隧道的当前状态
+func (t *Tunnel) Info() models.TunnelInfo {
+	t.mu.Lock()
+	status := "running"
+	if t.closed {
+		status = "stopped"
+	}
+	t.mu.Unlock()
+
+	return models.TunnelInfo{
+		ID:                t.ID,
+		ConfigID:          t.ConfigID,
+		Type:              t.Type,
+		ListenAddr:        t.ListenAddr,
+		TargetAddr:        t.TargetAddr,
+		Status:            status,
+		ActiveConnections: t.active.Load(),
+		TotalConnections:  t.total.Load(),
+		BytesSent:         t.bytesSent.Load(),
+		BytesReceived:     t.bytesReceived.Load(),
+		StartedAt:         t.StartedAt,
+	}
+}
+
+// serve 接受连接并转发，监听关闭后退出
+func (t *Tunnel) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			// 隧道已停止、监听已被重连换掉，或远程监听随连接断开（由重连重新监听并上报）时不上报错误
+			t.mu.Lock()
+			replaced := t.closed || t.listener != listener || errors.Is(err, ErrRemoteConnectionLost)
+			t.mu.Unlock()
+			if !replaced {
+				fmt.Printf("隧道 %s 监听失败: %v\n", t.ID, err)
+				info := t.Info()
+				info.Error = err.Error()
+				emitTunnelEvent(info)
+			}
+			return
+		}
+		go t.forward(conn)
+	}
+}
+
+// forward 为一个连接建立到目标的连接并双向复制数据
+func (t *Tunnel) forward(conn net.Conn) {
+	if !t.track(conn) {
+		conn.Close()
+		return
+	}
+	defer t.untrack(conn)
+
+	t.active.Add(1)
+	t.total.Add(1)
+	emitTunnelEvent(t.Info())
+	defer func() {
+		t.active.Add(-1)
+		emitTunnelEvent(t.Info())
+	}()
+
+	target, err := t.handle(conn)
+	if err != nil {
+		fmt.Printf("隧道 %s 连接 %s 失败: %v\n", t.ID, t.TargetAddr, err)
+		conn.Close()
+		return
+	}
+	if !t.track(target) {
+		conn.Close()
+		target.Close()
+		return
+	}
+	defer t.untrack(target)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		copyHalf(target, conn, &t.bytesSent)
+	}()
+	go func() {
+		defer wg.Done()
+		copyHalf(conn, target, &t.bytesReceived)
+	}()
+	wg.Wait()
+
+	conn.Close()
+	target.Close()
+}
+
+// copyHalf 单向复制数据并计数，源端结束后关闭目标的写方向
+func copyHalf(dst, src net.Conn, counter *atomic.Int64) {
+	io.Copy(&countingWriter{w: dst, n: counter}, src)
+
+	if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	} else {
+		dst.Close()
+	}
+}
+
+// countingWriter 统计写入字节数
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// track 记录连接以便停止隧道时关闭，隧道已停止时返回 false
+func (t *Tunnel) track(conn net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return false
+	}
+	t.conns[conn] = struct{}{}
+	return true
+}
+
+func (t *Tunnel) untrack(conn net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, conn)
+}
+
+// reportStats 流量有变化时定期推送统计
+func (t *Tunnel) reportStats() {
+	ticker := time.NewTicker(tunnelStatsInterval)
+	defer ticker.Stop()
+
+	var lastSent, lastReceived int64
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			sent, received := t.bytesSent.Load(), t.bytesReceived.Load()
+			if sent != lastSent || received != lastReceived {
+				lastSent, lastReceived = sent, received
+				emitTunnelEvent(t.Info())
+			}
+		}
+	}
+}
+
+// close 停止监听并关闭所有转发中的连接
+func (t *Tunnel) close() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	t.closed = true
+	close(t.done)
+	conns := t.conns
+	t.conns = make(map[net.Conn]struct{})
+	listener := t.listener
+	t.mu.Unlock()
+
+	listener.Close()
+	for conn := range conns {
+		conn.Close()
+	}
+}
+
+// newTunnelID 生成隧道 ID
+func newTunnelID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// normalizeListenAddr 只填写端口时默认监听本机回环地址
+func normalizeListenAddr(addr string) (string, error) {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr, nil
+	}
+	if _, err := net.LookupPort("tcp", addr); err != nil {
+		return "", fmt.Errorf("监听地址格式错误: %s", addr)
+	}
+	return net.JoinHostPort("127.0.0.1", addr), nil
+}
+
+// addTunnel 注册并启动隧道；建立监听期间会话已被关闭时停止隧道并返回错误
+func (sm *SessionManager) addTunnel(session *Session, t *Tunnel) (*models.TunnelInfo, error) {
+	t.ID = newTunnelID()
+	t.StartedAt = time.Now()
+	t.conns = make(map[net.Conn]struct{})
+	t.done = make(chan struct{})
+
+	sm.tunnelsMu.Lock()
+	sm.tunnels[t.ID] = t
+	sm.tunnelsMu.Unlock()
+
+	go t.serve(t.listener)
+	go t.reportStats()
+
+	// 关闭会话时在 sm.mu 下取走隧道，此时仍有效说明之后的关闭会停止该隧道
+	sm.mu.RLock()
+	current := sm.isCurrent(session)
+	sm.mu.RUnlock()
+	if !current {
+		sm.StopTunnel(t.ID)
+		return nil, fmt.Errorf("会话已关闭")
+	}
+
+	info := t.Info()
+	emitTunnelEvent(info)
+	return &info, nil
+}
+
+// StartLocalForward 启动本地端口转发（ssh -L），连接通过会话的 SSH 连接转发到 targetAddr
+func (sm *SessionManager) StartLocalForward(configID, listenAddr, targetAddr string) (*models.TunnelInfo, error) {
+	session, err := sm.GetSession(configID)
+	if err != nil {
+		return nil, err
+	}
+	return sm.startLocalForward(session, listenAddr, targetAddr)
+}
+
+// startLocalForward 为已取得的会话启动本地端口转发
+func (sm *SessionManager) startLocalForward(session *Session, listenAddr, targetAddr string) (*models.TunnelInfo, error) {
+	configID := session.Config.ID
+	listenAddr, err := normalizeListenAddr(listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(targetAddr); err != nil {
+		return nil, fmt.Errorf("转发目标格式错误: %s", targetAddr)
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("监听 %s 失败: %w", listenAddr, err)
+	}
+
+	return sm.addTunnel(session, &Tunnel{
+		ConfigID:   configID,
+		Type:       ForwardLocal,
+		ListenAddr: listener.Addr().String(),
+		TargetAddr: targetAddr,
+		listener:   listener,
+		handle: func(net.Conn) (net.Conn, error) {
+			// 每次连接时取当前会话，重连后使用新的 SSH 连接
+			session, err := sm.GetSession(configID)
+			if err != nil {
+				return nil, err
+			}
+			return session.Client().Dial("tcp", targetAddr)
+		},
+	})
+}
+
+// StartRemoteForward 启动远程端口转发（ssh -R），服务器上 listenAddr 收到的连接转发到本地 targetAddr
+func (sm *SessionManager) StartRemoteForward(configID, listenAddr, targetAddr string) (*models.TunnelInfo, error) {
+	session, err := sm.GetSession(configID)
+	if err != nil {
+		return nil, err
+	}
+	return sm.startRemoteForward(session, listenAddr, targetAddr)
+}
+
+// startRemoteForward 在会话的 SSH 连接上请求服务器监听，监听随会话关闭
+func (sm *SessionManager) startRemoteForward(session *Session, listenAddr, targetAddr string) (*models.TunnelInfo, error) {
+	listenAddr, err := normalizeListenAddr(listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(targetAddr); err != nil {
+		return nil, fmt.Errorf("转发目标格式错误: %s", targetAddr)
+	}
+
+	listener, err := listenRemote(session.Client(), listenAddr)
+	if err != nil {
+		if errors.Is(err, ErrRemoteForwardDenied) {
+			return nil, fmt.Errorf("服务器拒绝在 %s 上监听：请检查 sshd 的 AllowTcpForwarding 设置，监听非本机地址还需开启 GatewayPorts", listenAddr)
+		}
+		return nil, fmt.Errorf("请求远程监听 %s 失败: %w", listenAddr, err)
+	}
+
+	return sm.addTunnel(session, &Tunnel{
+		ConfigID:   session.Config.ID,
+		Type:       ForwardRemote,
+		ListenAddr: listener.Addr().String(),
+		TargetAddr: targetAddr,
+		listener:   listener,
+		handle: func(net.Conn) (net.Conn, error) {
+			return net.DialTimeout("tcp", targetAddr, 10*time.Second)
+		},
+	})
+}
+
+// StartDynamicForward 启动 SOCKS5 代理（ssh -D），username 非空时要求用户名密码认证
+func (sm *SessionManager) StartDynamicForward(configID, listenAddr, username, password string) (*models.TunnelInfo, error) {
+	session, err := sm.GetSession(configID)
+	if err != nil {
+		return nil, err
+	}
+	return sm.startDynamicForward(session, listenAddr, username, password)
+}
+
+// startDynamicForward 为已取得的会话启动 SOCKS5 代理
+func (sm *SessionManager) startDynamicForward(session *Session, listenAddr, username, password string) (*models.TunnelInfo, error) {
+	configID := session.Config.ID
+	listenAddr, err := normalizeListenAddr(listenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("监听 %s 失败: %w", listenAddr, err)
+	}
+
+	return sm.addTunnel(session, &Tunnel{
+		ConfigID:   configID,
+		Type:       ForwardDynamic,
+		ListenAddr: listener.Addr().String(),
+		TargetAddr: "SOCKS5",
+		listener:   listener,
+		handle: func(conn net.Conn) (net.Conn, error) {
+			return socksConnect(conn, username, password, func(addr string) (net.Conn, error) {
+				session, err := sm.GetSession(configID)
+				if err != nil {
+					return nil, err
+				}
+				return session.Client().Dial("tcp", addr)
+			})
+		},
+	})
+}
+
+// restartRemoteForwards 重连后在新连接上重新监听远程转发，尽量保持原来的地址
+func (sm *SessionManager) restartRemoteForwards(session *Session) {
+	sm.tunnelsMu.Lock()
+	var remotes []*Tunnel
+	for _, t := range sm.tunnels {
+		if t.ConfigID == session.Config.ID && t.Type == ForwardRemote {
+			remotes = append(remotes, t)
+		}
+	}
+	sm.tunnelsMu.Unlock()
+
+	for _, t := range remotes {
+		listener, err := listenRemote(session.Client(), t.ListenAddr)
+		if err != nil {
+			fmt.Printf("重新建立远程转发 %s 失败: %v\n", t.ListenAddr, err)
+			info := t.Info()
+			info.Error = err.Error()
+			emitTunnelEvent(info)
+			continue
+		}
+
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			listener.Close()
+			continue
+		}
+		// 换上新监听后，旧监听的 Accept 错误不再上报
+		t.listener = listener
+		t.mu.Unlock()
+
+		go t.serve(listener)
+		emitTunnelEvent(t.Info())
+	}
+}
+
+// StopTunnel 停止端口转发
+func (sm *SessionManager) StopTunnel(id string) error {
+	sm.tunnelsMu.Lock()
+	t, exists := sm.tunnels[id]
+	delete(sm.tunnels, id)
+	sm.tunnelsMu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("隧道不存在")
+	}
+
+	t.close()
+	emitTunnelEvent(t.Info())
+	return nil
+}
+
+// ListTunnels 列出端口转发，configID 为空时列出全部
+func (sm *SessionManager) ListTunnels(configID string) []models.TunnelInfo {
+	sm.tunnelsMu.Lock()
+	defer sm.tunnelsMu.Unlock()
+
+	tunnels := []models.TunnelInfo{}
+	for _, t := range sm.tunnels {
+		if configID == "" || t.ConfigID == configID {
+			tunnels = append(tunnels, t.Info())
+		}
+	}
+	return tunnels
+}
+
+// stopTunnels 停止会话的所有端口转发
+func (sm *SessionManager) stopTunnels(configID string) {
+	closeTunnels(sm.takeTunnels(configID))
+}
+
+// takeTunnels 移除会话的所有端口转发并返回，由调用方在释放 sm.mu 后调用 closeTunnels
+func (sm *SessionManager) takeTunnels(configID string) []*Tunnel {
+	sm.tunnelsMu.Lock()
+	defer sm.tunnelsMu.Unlock()
+
+	var taken []*Tunnel
+	for id, t := range sm.tunnels {
+		if t.ConfigID == configID {
+			taken = append(taken, t)
+			delete(sm.tunnels, id)
+		}
+	}
+	return taken
+}
+
+// closeTunnels 关闭端口转发，取消远程监听需要等待服务器，不能在持有 sm.mu 时调用
+func closeTunnels(tunnels []*Tunnel) {
+	for _, t := range tunnels {
+		t.close()
+		emitTunnelEvent(t.Info())
+	}
+}
+
+// startAutoForwards 启动配置中标记为自动启动的端口转发，失败只记录不影响连接
+func (sm *SessionManager) startAutoForwards(session *Session) {
+	config := session.Config
+	for _, forward := range config.Forwards {
+		if !forward.AutoStart {
+			continue
+		}
+		if err := sm.startForward(session, forward); err != nil {
+			fmt.Printf("自动启动端口转发 %s 失败: %v\n", forward.ListenAddr, err)
+			emitTunnelEvent(models.TunnelInfo{
+				ConfigID:   config.ID,
+				Type:       forward.Type,
+				ListenAddr: forward.ListenAddr,
+				TargetAddr: forward.TargetAddr,
+				Status:     "stopped",
+				Error:      err.Error(),
+			})
+		}
+	}
+}
+
+// startForward 按转发配置启动隧道
+func (sm *SessionManager) startForward(session *Session, forward models.Forward) error {
+	switch forward.Type {
+	case ForwardLocal, "":
+		_, err := sm.startLocalForward(session, forward.ListenAddr, forward.TargetAddr)
+		return err
+	case ForwardRemote:
+		_, err := sm.startRemoteForward(session, forward.ListenAddr, forward.TargetAddr)
+		return err
+	case ForwardDynamic:
+		_, err := sm.startDynamicForward(session, forward.ListenAddr, "", "")
+		return err
+	}
+	return fmt.Errorf("不支持的转发类型: %s", forward.Type)
+}
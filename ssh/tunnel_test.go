@@ -0,0 +1,53 @@
+package ssh
+
+import (
+	"net"
+	"testing"
+
+	"ssh-mdzz/models"
+)
+
+func TestForwardOnClosedSessionIsStopped(t *testing.T) {
+	sm := &SessionManager{
+		sessions: make(map[string]*Session),
+		tunnels:  make(map[string]*Tunnel),
+	}
+	// 会话在建立监听期间被关闭：已不在会话表中且不再活跃
+	session := &Session{ID: "closed", Config: &models.SSHConfig{ID: "closed"}}
+
+	tests := []struct {
+		name  string
+		start func(listenAddr string) (*models.TunnelInfo, error)
+	}{
+		{"本地转发", func(addr string) (*models.TunnelInfo, error) {
+			return sm.startLocalForward(session, addr, "127.0.0.1:1")
+		}},
+		{"SOCKS 代理", func(addr string) (*models.TunnelInfo, error) {
+			return sm.startDynamicForward(session, addr, "", "")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 先占用再释放一个端口，确认失败后监听已被关闭
+			probe, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			addr := probe.Addr().String()
+			probe.Close()
+
+			if info, err := tt.start(addr); err == nil {
+				t.Fatalf("会话已关闭时应返回错误，实际启动了 %+v", info)
+			}
+			if tunnels := sm.ListTunnels(""); len(tunnels) != 0 {
+				t.Fatalf("不应留下隧道: %+v", tunnels)
+			}
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				t.Fatalf("监听 %s 应已关闭: %v", addr, err)
+			}
+			listener.Close()
+		})
+	}
+}
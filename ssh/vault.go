@@ -0,0 +1,111 @@
+package ssh
+
+import (
+	"crypto"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"ssh-mdzz/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	keyResolver   func(id string) ([]byte, error)
+	keyResolverMu sync.RWMutex
+)
+
+// SetKeyResolver 设置按 ID 读取密钥库私钥的回调
+func SetKeyResolver(resolver func(id string) ([]byte, error)) {
+	keyResolverMu.Lock()
+	defer keyResolverMu.Unlock()
+	keyResolver = resolver
+}
+
+// loadVaultKeySigner 从密钥库读取私钥并解析，私钥内容只在内存中使用
+func loadVaultKeySigner(keyID, passphrase, configName string, interactive bool) (ssh.Signer, error) {
+	keyResolverMu.RLock()
+	resolver := keyResolver
+	keyResolverMu.RUnlock()
+
+	if resolver == nil {
+		return nil, fmt.Errorf("密钥库不可用")
+	}
+
+	key, err := resolver(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKeySigner(key, "vault:"+keyID, passphrase, configName, interactive)
+}
+
+// ReadPrivateKey 解析私钥（加密私钥需提供密码），返回不带密码的 OpenSSH 格式私钥和公钥信息
+func ReadPrivateKey(data []byte, passphrase string) ([]byte, *models.VaultKey, error) {
+	var rawKey interface{}
+	var err error
+	if passphrase != "" {
+		rawKey, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+	} else {
+		rawKey, err = ssh.ParseRawPrivateKey(data)
+	}
+	if err != nil {
+		var missingErr *ssh.PassphraseMissingError
+		if errors.As(err, &missingErr) {
+			return nil, nil, fmt.Errorf("私钥已加密，请提供密钥密码")
+		}
+		return nil, nil, passphraseError(err)
+	}
+
+	return encodeVaultKey(rawKey, "")
+}
+
+// encodeVaultKey 将私钥编码为 OpenSSH 格式，并生成对应的公钥信息
+func encodeVaultKey(rawKey interface{}, comment string) ([]byte, *models.VaultKey, error) {
+	signer, err := ssh.NewSignerFromKey(rawKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("不支持的私钥类型: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(crypto.PrivateKey(rawKey), comment)
+	if err != nil {
+		return nil, nil, fmt.Errorf("编码私钥失败: %w", err)
+	}
+
+	return pem.EncodeToMemory(block), vaultKeyInfo(signer.PublicKey(), comment), nil
+}
+
+// vaultKeyInfo 生成公钥的类型、指纹和 authorized_keys 格式
+func vaultKeyInfo(pub ssh.PublicKey, comment string) *models.VaultKey {
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	if comment != "" {
+		authorizedKey += " " + comment
+	}
+	return &models.VaultKey{
+		Type:        pub.Type(),
+		Fingerprint: ssh.FingerprintSHA256(pub),
+		PublicKey:   authorizedKey,
+	}
+}
+
+// EncodePrivateKey 将密钥库中的私钥重新编码以便导出，passphrase 非空时加密
+func EncodePrivateKey(data []byte, passphrase, comment string) ([]byte, error) {
+	rawKey, err := ssh.ParseRawPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析密钥失败: %w", err)
+	}
+
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(crypto.PrivateKey(rawKey), comment, []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(crypto.PrivateKey(rawKey), comment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("编码私钥失败: %w", err)
+	}
+	return pem.EncodeToMemory(block), nil
+}
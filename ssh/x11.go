@@ -0,0 +1,314 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// x11AuthProtocol X11 转发使用的认证协议
+const x11AuthProtocol = "MIT-MAGIC-COOKIE-1"
+
+// x11Display 本地 X 显示
+type x11Display struct {
+	network string // unix 或 tcp
+	address string
+	number  string // 显示编号，用于在 Xauthority 中查找 cookie
+	screen  uint32
+}
+
+// x11Forwarder 会话的 X11 转发状态，远程程序使用伪造的 cookie，转发到本地时替换为真实 cookie
+type x11Forwarder struct {
+	display    x11Display
+	fakeCookie []byte
+	realCookie []byte // 本地未配置认证时为空
+}
+
+// parseX11Display 解析 DISPLAY 环境变量，如 :0、:0.0、localhost:10.0、/tmp/launch-xxx/org.xquartz:0
+func parseX11Display(display string) (x11Display, error) {
+	idx := strings.LastIndex(display, ":")
+	if display == "" || idx < 0 {
+		return x11Display{}, fmt.Errorf("未检测到 X 服务器（DISPLAY 未设置）")
+	}
+
+	host, rest := display[:idx], display[idx+1:]
+	number, screen := rest, ""
+	if dot := strings.Index(rest, "."); dot >= 0 {
+		number, screen = rest[:dot], rest[dot+1:]
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return x11Display{}, fmt.Errorf("DISPLAY 格式错误: %s", display)
+	}
+
+	result := x11Display{number: number}
+	if screen != "" {
+		s, err := strconv.Atoi(screen)
+		if err != nil {
+			return x11Display{}, fmt.Errorf("DISPLAY 格式错误: %s", display)
+		}
+		result.screen = uint32(s)
+	}
+
+	switch {
+	case strings.HasPrefix(host, "/"):
+		// macOS XQuartz 使用完整的套接字路径
+		result.network, result.address = "unix", display
+		if _, err := os.Stat(display); err != nil {
+			result.address = host
+		}
+	case host == "" || host == "unix":
+		result.network, result.address = "unix", fmt.Sprintf("/tmp/.X11-unix/X%d", n)
+	default:
+		result.network, result.address = "tcp", net.JoinHostPort(host, strconv.Itoa(6000+n))
+	}
+	return result, nil
+}
+
+// xauthorityCookie 从 Xauthority 文件中读取显示对应的 MIT-MAGIC-COOKIE-1
+func xauthorityCookie(display x11Display) ([]byte, error) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	r := bytes.NewReader(data)
+	readField := func() ([]byte, error) {
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return nil, err
+		}
+		field := make([]byte, length)
+		_, err := io.ReadFull(r, field)
+		return field, err
+	}
+
+	for {
+		var family uint16
+		if err := binary.Read(r, binary.BigEndian, &family); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("Xauthority 中没有显示 %s 的 cookie", display.number)
+			}
+			return nil, err
+		}
+		address, err := readField()
+		if err != nil {
+			return nil, err
+		}
+		number, err := readField()
+		if err != nil {
+			return nil, err
+		}
+		name, err := readField()
+		if err != nil {
+			return nil, err
+		}
+		cookie, err := readField()
+		if err != nil {
+			return nil, err
+		}
+
+		// 256 为 FamilyLocal，65535 为 FamilyWild
+		localMatch := family == 65535 || (family == 256 && string(address) == hostname)
+		if display.network == "tcp" {
+			localMatch = true
+		}
+		if localMatch && string(number) == display.number && string(name) == x11AuthProtocol {
+			return cookie, nil
+		}
+	}
+}
+
+// RequestX11Forwarding 为终端会话请求 X11 转发（配置未开启时不做任何事）
+func (s *Session) RequestX11Forwarding(sshSession *ssh.Session) error {
+	if !s.Config.X11Forwarding {
+		return nil
+	}
+
+	forwarder, err := s.x11Forwarder()
+	if err != nil {
+		return err
+	}
+
+	payload := struct {
+		SingleConnection bool
+		AuthProtocol     string
+		AuthCookie       string
+		ScreenNumber     uint32
+	}{
+		AuthProtocol: x11AuthProtocol,
+		AuthCookie:   hex.EncodeToString(forwarder.fakeCookie),
+		ScreenNumber: forwarder.display.screen,
+	}
+
+	ok, err := sshSession.SendRequest("x11-req", true, ssh.Marshal(&payload))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("服务器拒绝 X11 转发（请检查 sshd 的 X11Forwarding 设置）")
+	}
+	return nil
+}
+
+// x11Forwarder 返回会话的 X11 转发器，首次调用时在连接上注册 x11 通道处理
+func (s *Session) x11Forwarder() (*x11Forwarder, error) {
+	s.x11Mu.Lock()
+	defer s.x11Mu.Unlock()
+
+	if s.x11 != nil {
+		return s.x11, nil
+	}
+
+	display, err := parseX11Display(os.Getenv("DISPLAY"))
+	if err != nil {
+		return nil, err
+	}
+
+	realCookie, err := xauthorityCookie(display)
+	if err != nil {
+		// 本地 X 服务器可能未启用认证，转发时不携带 cookie
+		fmt.Printf("X11 转发: 读取本地 cookie 失败: %v\n", err)
+		realCookie = nil
+	}
+
+	fakeCookie := make([]byte, 16)
+	if _, err := rand.Read(fakeCookie); err != nil {
+		return nil, err
+	}
+
+	forwarder := &x11Forwarder{
+		display:    display,
+		fakeCookie: fakeCookie,
+		realCookie: realCookie,
+	}
+	if err := forwarder.serve(s.Client()); err != nil {
+		return nil, err
+	}
+
+	s.x11 = forwarder
+	return forwarder, nil
+}
+
+// serve 在连接上注册 x11 通道处理，重连后在新连接上沿用同一个 cookie
+func (f *x11Forwarder) serve(client *ssh.Client) error {
+	channels := client.HandleChannelOpen("x11")
+	if channels == nil {
+		return fmt.Errorf("X11 通道处理已被注册")
+	}
+	go func() {
+		for newChannel := range channels {
+			go f.handle(newChannel)
+		}
+	}()
+	return nil
+}
+
+// handle 接受服务器打开的 x11 通道，校验并替换 cookie 后转发到本地显示
+func (f *x11Forwarder) handle(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	defer channel.Close()
+
+	setup, err := f.rewriteSetup(channel)
+	if err != nil {
+		fmt.Printf("X11 转发: %v\n", err)
+		return
+	}
+
+	local, err := net.DialTimeout(f.display.network, f.display.address, 10*time.Second)
+	if err != nil {
+		fmt.Printf("X11 转发: 连接本地显示 %s 失败: %v\n", f.display.address, err)
+		return
+	}
+	defer local.Close()
+
+	if _, err := local.Write(setup); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(local, channel)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(channel, local)
+		channel.CloseWrite()
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// rewriteSetup 读取 X11 连接建立请求，校验伪造的 cookie 并替换为真实 cookie
+func (f *x11Forwarder) rewriteSetup(r io.Reader) ([]byte, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("读取 X11 连接请求失败: %w", err)
+	}
+
+	var order binary.ByteOrder
+	switch header[0] {
+	case 'B':
+		order = binary.BigEndian
+	case 'l':
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("X11 连接请求格式错误")
+	}
+
+	nameLen := int(order.Uint16(header[6:8]))
+	dataLen := int(order.Uint16(header[8:10]))
+	pad := func(n int) int { return (n + 3) &^ 3 }
+
+	body := make([]byte, pad(nameLen)+pad(dataLen))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("读取 X11 认证信息失败: %w", err)
+	}
+	name := string(body[:nameLen])
+	data := body[pad(nameLen) : pad(nameLen)+dataLen]
+
+	if name != x11AuthProtocol || !bytes.Equal(data, f.fakeCookie) {
+		return nil, fmt.Errorf("X11 认证 cookie 不匹配，已拒绝连接")
+	}
+
+	var authName, authData []byte
+	if f.realCookie != nil {
+		authName, authData = []byte(x11AuthProtocol), f.realCookie
+	}
+
+	setup := make([]byte, 12, 12+pad(len(authName))+pad(len(authData)))
+	copy(setup, header)
+	order.PutUint16(setup[6:8], uint16(len(authName)))
+	order.PutUint16(setup[8:10], uint16(len(authData)))
+	setup = append(setup, authName...)
+	setup = append(setup, make([]byte, pad(len(authName))-len(authName))...)
+	setup = append(setup, authData...)
+	setup = append(setup, make([]byte, pad(len(authData))-len(authData))...)
+	return setup, nil
+}
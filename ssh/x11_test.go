@@ -0,0 +1,143 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// x11Setup 构造 X11 连接建立请求
+func x11Setup(order byte, name string, data []byte) []byte {
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if order == 'B' {
+		byteOrder = binary.BigEndian
+	}
+	pad := func(n int) int { return (n + 3) &^ 3 }
+
+	setup := make([]byte, 12)
+	setup[0] = order
+	byteOrder.PutUint16(setup[2:4], 11) // 协议主版本
+	byteOrder.PutUint16(setup[6:8], uint16(len(name)))
+	byteOrder.PutUint16(setup[8:10], uint16(len(data)))
+	setup = append(setup, name...)
+	setup = append(setup, make([]byte, pad(len(name))-len(name))...)
+	setup = append(setup, data...)
+	return append(setup, make([]byte, pad(len(data))-len(data))...)
+}
+
+func TestX11RewriteSetup(t *testing.T) {
+	fake := bytes.Repeat([]byte{0xaa}, 16)
+	genuine := bytes.Repeat([]byte{0x55}, 16)
+
+	tests := []struct {
+		name    string
+		genuine []byte
+		input   []byte
+		want    []byte
+		wantErr bool
+	}{
+		{"小端替换 cookie", genuine, x11Setup('l', x11AuthProtocol, fake), x11Setup('l', x11AuthProtocol, genuine), false},
+		{"大端替换 cookie", genuine, x11Setup('B', x11AuthProtocol, fake), x11Setup('B', x11AuthProtocol, genuine), false},
+		{"本地未配置认证", nil, x11Setup('l', x11AuthProtocol, fake), x11Setup('l', "", nil), false},
+		{"cookie 不匹配", genuine, x11Setup('l', x11AuthProtocol, genuine), nil, true},
+		{"认证协议不同", genuine, x11Setup('l', "XDM-AUTHORIZATION-1", fake), nil, true},
+		{"没有认证信息", genuine, x11Setup('l', "", nil), nil, true},
+		{"字节序错误", genuine, append([]byte{'x'}, x11Setup('l', x11AuthProtocol, fake)[1:]...), nil, true},
+		{"请求被截断", genuine, x11Setup('l', x11AuthProtocol, fake)[:20], nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &x11Forwarder{fakeCookie: fake, realCookie: tt.genuine}
+			got, err := f.rewriteSetup(bytes.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rewriteSetup() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("rewriteSetup() = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseX11Display(t *testing.T) {
+	tests := []struct {
+		display string
+		want    x11Display
+		wantErr bool
+	}{
+		{":0", x11Display{network: "unix", address: "/tmp/.X11-unix/X0", number: "0"}, false},
+		{":1.2", x11Display{network: "unix", address: "/tmp/.X11-unix/X1", number: "1", screen: 2}, false},
+		{"unix:3", x11Display{network: "unix", address: "/tmp/.X11-unix/X3", number: "3"}, false},
+		{"localhost:10.0", x11Display{network: "tcp", address: "localhost:6010", number: "10"}, false},
+		{"/tmp/launch-abc/org.xquartz:0", x11Display{network: "unix", address: "/tmp/launch-abc/org.xquartz", number: "0"}, false},
+		{"", x11Display{}, true},
+		{"localhost", x11Display{}, true},
+		{":x", x11Display{}, true},
+		{":0.x", x11Display{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.display, func(t *testing.T) {
+			got, err := parseX11Display(tt.display)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseX11Display(%q) 错误 = %v，期望出错 %v", tt.display, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("parseX11Display(%q) = %+v，期望 %+v", tt.display, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXauthorityCookie(t *testing.T) {
+	hostname, _ := os.Hostname()
+	entry := func(family uint16, address, number, name string, cookie []byte) []byte {
+		var b bytes.Buffer
+		binary.Write(&b, binary.BigEndian, family)
+		for _, field := range [][]byte{[]byte(address), []byte(number), []byte(name), cookie} {
+			binary.Write(&b, binary.BigEndian, uint16(len(field)))
+			b.Write(field)
+		}
+		return b.Bytes()
+	}
+
+	path := filepath.Join(t.TempDir(), "Xauthority")
+	data := bytes.Join([][]byte{
+		entry(256, "other-host", "0", x11AuthProtocol, []byte("other")),
+		entry(256, hostname, "1", x11AuthProtocol, []byte("display-1")),
+		entry(256, hostname, "0", "XDM-AUTHORIZATION-1", []byte("xdm")),
+		entry(256, hostname, "0", x11AuthProtocol, []byte("local-0")),
+		entry(65535, "", "2", x11AuthProtocol, []byte("wild-2")),
+	}, nil)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XAUTHORITY", path)
+
+	tests := []struct {
+		name    string
+		display x11Display
+		want    string
+		wantErr bool
+	}{
+		{"本机显示", x11Display{network: "unix", number: "0"}, "local-0", false},
+		{"通配地址", x11Display{network: "unix", number: "2"}, "wild-2", false},
+		{"TCP 显示忽略地址", x11Display{network: "tcp", number: "1"}, "display-1", false},
+		{"没有对应显示", x11Display{network: "unix", number: "9"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cookie, err := xauthorityCookie(tt.display)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("xauthorityCookie() 错误 = %v，期望出错 %v", err, tt.wantErr)
+			}
+			if string(cookie) != tt.want {
+				t.Fatalf("xauthorityCookie() = %q，期望 %q", cookie, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ssh-mdzz/fileutil"
+	"ssh-mdzz/models"
+)
+
+const (
+	// maxBackups 保留的配置文件备份数量
+	maxBackups = 10
+	// backupTimeFormat 备份文件名中的时间格式，按字典序即按时间排序
+	backupTimeFormat = "20060102-150405.000"
+)
+
+// backupPrefix 备份文件名前缀，备份与配置文件位于同一目录
+func (s *Store) backupPrefix() string {
+	return filepath.Base(s.filePath) + ".bak-"
+}
+
+// backupNoLock 将当前配置文件复制为带时间戳的备份，并删除超出数量的旧备份
+func (s *Store) backupNoLock() error {
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	name := s.backupPrefix() + time.Now().Format(backupTimeFormat)
+	if err := fileutil.WriteFileAtomic(filepath.Join(filepath.Dir(s.filePath), name), data, 0600); err != nil {
+		return err
+	}
+
+	names, err := s.backupNamesNoLock()
+	if err != nil {
+		return err
+	}
+	for len(names) > maxBackups {
+		if err := os.Remove(filepath.Join(filepath.Dir(s.filePath), names[len(names)-1])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		names = names[:len(names)-1]
+	}
+	return nil
+}
+
+// backupNamesNoLock 列出备份文件名，最新的在前
+func (s *Store) backupNamesNoLock() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(s.filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := s.backupPrefix()
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), prefix) {
+			if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(entry.Name(), prefix)); err == nil {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// ListBackups 列出配置文件备份，最新的在前
+func (s *Store) ListBackups() ([]models.BackupInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names, err := s.backupNamesNoLock()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]models.BackupInfo, 0, len(names))
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(filepath.Dir(s.filePath), name))
+		if err != nil {
+			continue
+		}
+		createdAt, _ := time.ParseInLocation(backupTimeFormat, strings.TrimPrefix(name, s.backupPrefix()), time.Local)
+		backups = append(backups, models.BackupInfo{
+			Name:      name,
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+	return backups, nil
+}
+
+// RestoreBackup 用当前密钥校验备份后恢复，恢复前的配置文件会先被备份
+func (s *Store) RestoreBackup(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userKey == "" {
+		return errors.New("未设置加密密钥")
+	}
+
+	// 只接受备份列表中的文件名，防止路径穿越
+	names, err := s.backupNamesNoLock()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, n := range names {
+		if n == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("备份不存在: %s", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(s.filePath), name))
+	if err != nil {
+		return fmt.Errorf("读取备份失败: %w", err)
+	}
+
+	// 解密备份会换用备份的 KDF 参数，任何一步失败都恢复当前状态
+	prevKDF, prevDerived := s.kdf, s.derivedKey
+	prevConfigs, prevKeys := s.configs, s.keys
+	restore := func() {
+		s.kdf, s.derivedKey = prevKDF, prevDerived
+		s.configs, s.keys = prevConfigs, prevKeys
+	}
+
+	vault, _, err := s.decodeVaultNoLock(data)
+	if err != nil {
+		restore()
+		return fmt.Errorf("备份无法用当前密钥解密: %w", err)
+	}
+
+	s.configs, s.keys = vault.Configs, vault.Keys
+	if err := s.saveConfigsNoLock(); err != nil {
+		restore()
+		return fmt.Errorf("恢复备份失败: %w", err)
+	}
+
+	fmt.Printf("RestoreBackup: 已从 %s 恢复 %d 个配置\n", name, len(vault.Configs))
+	return nil
+}
+
+// readBackupsNoLock 用当前密钥解密所有备份，无法解密的备份（更早的密钥加密）对应的值为 nil
+// 解密会改变缓存的派生参数，调用方需自行恢复
+func (s *Store) readBackupsNoLock() (map[string]*vaultData, error) {
+	names, err := s.backupNamesNoLock()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make(map[string]*vaultData, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(s.filePath), name))
+		if err != nil {
+			return nil, err
+		}
+		vault, _, err := s.decodeVaultNoLock(data)
+		if err != nil {
+			fmt.Printf("readBackupsNoLock: 备份 %s 无法解密: %v\n", name, err)
+			vault = nil
+		}
+		backups[name] = vault
+	}
+	return backups, nil
+}
+
+// reencryptBackupsNoLock 更换密钥后用新密钥重写备份，保存时新产生的备份内容为 current。
+// 无法用原密钥解密或重写失败的备份保持原样，返回这些备份的文件名
+func (s *Store) reencryptBackupsNoLock(backups map[string]*vaultData, current *vaultData) []string {
+	names, err := s.backupNamesNoLock()
+	if err != nil {
+		fmt.Printf("reencryptBackupsNoLock: 列出备份失败: %v\n", err)
+		return nil
+	}
+
+	key, err := s.deriveKeyNoLock(s.kdf)
+	if err != nil {
+		fmt.Printf("reencryptBackupsNoLock: 派生密钥失败: %v\n", err)
+		return names
+	}
+
+	dir := filepath.Dir(s.filePath)
+	var kept []string
+	for _, name := range names {
+		vault, existed := backups[name]
+		if !existed {
+			vault = current
+		}
+		if vault == nil {
+			kept = append(kept, name)
+			continue
+		}
+
+		data, err := encodeVault(vault, s.kdf, key)
+		if err == nil {
+			err = fileutil.WriteFileAtomic(filepath.Join(dir, name), data, 0600)
+		}
+		if err != nil {
+			fmt.Printf("reencryptBackupsNoLock: 重新加密备份 %s 失败: %v\n", name, err)
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// upgradeBackupsNoLock 配置文件迁移后，用当前的派生参数重新加密仍为旧格式的备份，
+// 避免弱派生的文件继续留在磁盘上；无法解密的备份保持不变
+func (s *Store) upgradeBackupsNoLock() {
+	names, err := s.backupNamesNoLock()
+	if err != nil {
+		fmt.Printf("upgradeBackupsNoLock: 列出备份失败: %v\n", err)
+		return
+	}
+
+	params, derived := s.kdf, s.derivedKey
+	dir := filepath.Dir(s.filePath)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("upgradeBackupsNoLock: 读取备份 %s 失败: %v\n", name, err)
+			continue
+		}
+
+		// 解密会改变缓存的派生参数，每个备份处理完后恢复
+		vault, migrate, err := s.decodeVaultNoLock(data)
+		s.kdf, s.derivedKey = params, derived
+		if err != nil {
+			fmt.Printf("upgradeBackupsNoLock: 备份 %s 无法解密，保持不变: %v\n", name, err)
+			continue
+		}
+		if !migrate {
+			continue
+		}
+
+		data, err = encodeVault(vault, params, derived)
+		if err == nil {
+			err = fileutil.WriteFileAtomic(path, data, 0600)
+		}
+		if err != nil {
+			fmt.Printf("upgradeBackupsNoLock: 重新加密备份 %s 失败: %v\n", name, err)
+		}
+	}
+}
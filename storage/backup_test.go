@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssh-mdzz/models"
+)
+
+// writeTestBackups 按时间从旧到新写入 count 个备份，返回文件名
+func writeTestBackups(t *testing.T, s *Store, count int, data []byte) []string {
+	t.Helper()
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local)
+	names := make([]string, count)
+	for i := range names {
+		names[i] = s.backupPrefix() + base.Add(time.Duration(i)*time.Minute).Format(backupTimeFormat)
+		if err := os.WriteFile(filepath.Join(filepath.Dir(s.filePath), names[i]), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return names
+}
+
+func TestBackupRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing int
+		want     int
+	}{
+		{"没有备份", 0, 1},
+		{"未达到上限", maxBackups - 2, maxBackups - 1},
+		{"达到上限", maxBackups, maxBackups},
+		{"超出上限", maxBackups + 3, maxBackups},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "secret")
+			if err := os.WriteFile(s.filePath, []byte("current"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			existing := writeTestBackups(t, s, tt.existing, []byte("old"))
+
+			if err := s.backupNoLock(); err != nil {
+				t.Fatalf("backupNoLock: %v", err)
+			}
+
+			names, err := s.backupNamesNoLock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(names) != tt.want {
+				t.Fatalf("备份数量为 %d，期望 %d", len(names), tt.want)
+			}
+
+			// 最新的备份是当前文件，被删除的是最旧的备份
+			data, _ := os.ReadFile(filepath.Join(filepath.Dir(s.filePath), names[0]))
+			if string(data) != "current" {
+				t.Fatalf("最新备份内容为 %q", data)
+			}
+			if dropped := tt.existing + 1 - tt.want; dropped > 0 {
+				for _, name := range existing[:dropped] {
+					if _, err := os.Stat(filepath.Join(filepath.Dir(s.filePath), name)); !os.IsNotExist(err) {
+						t.Fatalf("最旧的备份 %s 应被删除", name)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBackupNamesIgnoreOtherFiles(t *testing.T) {
+	s := newTestStore(t, "secret")
+	dir := filepath.Dir(s.filePath)
+	valid := writeTestBackups(t, s, 2, []byte("old"))
+	for _, name := range []string{
+		s.backupPrefix() + "not-a-time",
+		s.backupPrefix() + "20250101-120000.000.tmp",
+		"other.enc.bak-20250101-120000.000",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, s.backupPrefix()+"20250101-130000.000"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := s.backupNamesNoLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != valid[1] || names[1] != valid[0] {
+		t.Fatalf("备份列表为 %v，期望 [%s %s]", names, valid[1], valid[0])
+	}
+}
+
+func TestRestoreBackupValidatesName(t *testing.T) {
+	s := newTestStore(t, "secret")
+	restored := []models.SSHConfig{{ID: "r", Name: "restored", Host: "10.0.0.9", Port: "22", Username: "root"}}
+	backups := writeTestBackups(t, s, 1, encodeStoreFile(t, restored, "secret"))
+
+	// 备份目录外的文件不能被当作备份恢复
+	outside := filepath.Join(t.TempDir(), "evil.enc")
+	if err := os.WriteFile(outside, encodeStoreFile(t, nil, "secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		backup  string
+		wantErr bool
+	}{
+		{"路径穿越", "../evil.enc", true},
+		{"绝对路径", outside, true},
+		{"不存在的备份", s.backupPrefix() + "20990101-000000.000", true},
+		{"配置文件本身", filepath.Base(s.filePath), true},
+		{"空名称", "", true},
+		{"列表中的备份", backups[0], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.RestoreBackup(tt.backup)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RestoreBackup(%q) 错误 = %v，期望出错 %v", tt.backup, err, tt.wantErr)
+			}
+		})
+	}
+
+	configs := s.GetAllConfigs()
+	if len(configs) != 1 || configs[0].ID != "r" {
+		t.Fatalf("恢复后的配置不正确: %+v", configs)
+	}
+}
+
+func TestRestoreBackupWithWrongKey(t *testing.T) {
+	s := newTestStore(t, "secret")
+	s.configs = testConfigs()
+	if err := s.SaveConfigs(); err != nil {
+		t.Fatal(err)
+	}
+	backups := writeTestBackups(t, s, 1, encodeStoreFile(t, nil, "other"))
+	salt, derived := s.kdf.Salt, s.derivedKey
+
+	if err := s.RestoreBackup(backups[0]); err == nil {
+		t.Fatal("其他密钥加密的备份不应被恢复")
+	}
+	if configs := s.GetAllConfigs(); len(configs) != 2 {
+		t.Fatalf("恢复失败后配置应保持不变，实际有 %d 个", len(configs))
+	}
+	if !bytes.Equal(s.kdf.Salt, salt) || !bytes.Equal(s.derivedKey, derived) {
+		t.Fatal("恢复失败后应继续使用原来的 KDF 参数和派生密钥")
+	}
+}
+
+func TestChangeUserKeyReencryptsBackups(t *testing.T) {
+	s := newTestStore(t, "old")
+	s.configs = testConfigs()
+	if err := s.SaveConfigs(); err != nil {
+		t.Fatal(err)
+	}
+	backups := writeTestBackups(t, s, 2, encodeStoreFile(t, testConfigs(), "old"))
+	unreadable := s.backupPrefix() + "20240101-120000.000"
+	if err := os.WriteFile(filepath.Join(filepath.Dir(s.filePath), unreadable),
+		encodeStoreFile(t, nil, "older"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 更早的密钥加密的备份无法重新加密，保持原样，不影响密钥更换
+	before, err := os.ReadFile(filepath.Join(filepath.Dir(s.filePath), unreadable))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ChangeUserKey("old", "new"); err != nil {
+		t.Fatalf("ChangeUserKey: %v", err)
+	}
+	after, err := os.ReadFile(filepath.Join(filepath.Dir(s.filePath), unreadable))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatalf("无法解密的备份 %s 应保持原样", unreadable)
+	}
+
+	for _, name := range backups {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(s.filePath), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := newTestStore(t, "new")
+		if _, _, err := check.decodeVaultNoLock(data); err != nil {
+			t.Fatalf("备份 %s 应已用新密钥加密: %v", name, err)
+		}
+	}
+}
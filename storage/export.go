@@ -0,0 +1,490 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"ssh-mdzz/crypto"
+	"ssh-mdzz/models"
+)
+
+const (
+	bundleFormat  = "ssh-mdzz-bundle"
+	bundleVersion = 1
+	// bundleCheckText 用于校验导出密码是否正确
+	bundleCheckText = "ssh-mdzz-bundle-check"
+)
+
+// 导入冲突处理策略
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictKeepBoth  = "keep"
+)
+
+// exportBundle 导出文件结构，配置和密钥整体用导出密码加密为 Data，明文中只有格式、版本、创建时间和派生参数
+type exportBundle struct {
+	Format    string           `json:"format"`
+	Version   int              `json:"version"`
+	CreatedAt time.Time        `json:"createdAt"`
+	KDF       crypto.KDFParams `json:"kdf"`
+	Check     string           `json:"check"`
+	Data      string           `json:"data"` // 加密的 vaultData
+}
+
+var aliasInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// selectConfigsNoLock 按 ID 选取配置，ids 为空时返回全部
+func (s *Store) selectConfigsNoLock(ids []string) ([]models.SSHConfig, error) {
+	if len(ids) == 0 {
+		return append([]models.SSHConfig(nil), s.configs...), nil
+	}
+
+	selected := make([]models.SSHConfig, 0, len(ids))
+	for _, id := range ids {
+		found := false
+		for _, config := range s.configs {
+			if config.ID == id {
+				selected = append(selected, config)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("配置不存在: %s", id)
+		}
+	}
+	return selected, nil
+}
+
+// ExportSSHConfigSnippet 将配置导出为 OpenSSH config 片段（不包含密码等敏感信息）
+func (s *Store) ExportSSHConfigSnippet(ids []string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	configs, err := s.selectConfigsNoLock(ids)
+	if err != nil {
+		return "", err
+	}
+
+	// 先为每个配置分配唯一的 Host 别名，供 ProxyJump 引用
+	aliases := make(map[string]string, len(configs))
+	used := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		alias := aliasInvalidChars.ReplaceAllString(strings.TrimSpace(config.Name), "-")
+		alias = strings.Trim(alias, "-")
+		if alias == "" {
+			alias = config.Host
+		}
+		base := alias
+		for i := 2; used[alias]; i++ {
+			alias = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[alias] = true
+		aliases[config.ID] = alias
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# 由 SSH MDZZ 导出于 %s\n", time.Now().Format("2006-01-02 15:04:05")))
+	for _, config := range configs {
+		b.WriteString("\n")
+		if config.Name != "" && config.Name != aliases[config.ID] {
+			b.WriteString(fmt.Sprintf("# %s\n", config.Name))
+		}
+		b.WriteString(fmt.Sprintf("Host %s\n", aliases[config.ID]))
+		b.WriteString(fmt.Sprintf("    HostName %s\n", config.Host))
+		if config.Username != "" {
+			b.WriteString(fmt.Sprintf("    User %s\n", config.Username))
+		}
+		if config.Port != "" && config.Port != "22" {
+			b.WriteString(fmt.Sprintf("    Port %s\n", config.Port))
+		}
+		if config.KeyPath != "" {
+			b.WriteString(fmt.Sprintf("    IdentityFile %s\n", quoteSSHArg(config.KeyPath)))
+		}
+		if config.CertPath != "" {
+			b.WriteString(fmt.Sprintf("    CertificateFile %s\n", quoteSSHArg(config.CertPath)))
+		}
+		if config.AgentForwarding {
+			b.WriteString("    ForwardAgent yes\n")
+		}
+		if len(config.JumpHosts) > 0 {
+			b.WriteString(fmt.Sprintf("    ProxyJump %s\n", s.proxyJumpSpecNoLock(config.JumpHosts, aliases)))
+		}
+	}
+
+	return b.String(), nil
+}
+
+// proxyJumpSpecNoLock 生成 ProxyJump 参数，已导出的配置使用其别名
+func (s *Store) proxyJumpSpecNoLock(jumpHosts []models.JumpHost, aliases map[string]string) string {
+	specs := make([]string, 0, len(jumpHosts))
+	for _, jump := range jumpHosts {
+		if jump.ConfigID != "" {
+			if alias, ok := aliases[jump.ConfigID]; ok {
+				specs = append(specs, alias)
+				continue
+			}
+			for _, config := range s.configs {
+				if config.ID == jump.ConfigID {
+					jump = models.JumpHost{Host: config.Host, Port: config.Port, Username: config.Username}
+					break
+				}
+			}
+		}
+
+		spec := jump.Host
+		if jump.Port != "" && jump.Port != "22" {
+			spec = net.JoinHostPort(jump.Host, jump.Port)
+		}
+		if jump.Username != "" {
+			spec = jump.Username + "@" + spec
+		}
+		specs = append(specs, spec)
+	}
+	return strings.Join(specs, ",")
+}
+
+// quoteSSHArg 参数包含空白时加引号
+func quoteSSHArg(arg string) string {
+	if strings.ContainsAny(arg, " \t") {
+		return `"` + arg + `"`
+	}
+	return arg
+}
+
+// ExportBundle 将配置导出为可在其他机器导入的文件，配置（包括主机名、用户名）和引用的私钥
+// 整体使用导出密码加密
+func (s *Store) ExportBundle(ids []string, filePath, passphrase string) error {
+	if passphrase == "" {
+		return errors.New("导出密码不能为空")
+	}
+
+	s.mu.RLock()
+	configs, err := s.selectConfigsNoLock(ids)
+	var keys []vaultKey
+	if err == nil {
+		configs = s.withJumpConfigsNoLock(configs)
+		keys, err = s.referencedVaultKeysNoLock(configs)
+	}
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// 导出文件使用独立的盐派生密钥
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		return err
+	}
+	key, err := crypto.DeriveKey(passphrase, params)
+	if err != nil {
+		return err
+	}
+
+	check, err := crypto.Encrypt(bundleCheckText, key)
+	if err != nil {
+		return err
+	}
+
+	exportKeys := make([]vaultKey, len(keys))
+	for i, vk := range keys {
+		vk.UsedBy = nil
+		exportKeys[i] = vk
+	}
+	plaintext, err := json.Marshal(&vaultData{Configs: configs, Keys: exportKeys})
+	if err != nil {
+		return err
+	}
+	sealed, err := crypto.Encrypt(string(plaintext), key)
+	if err != nil {
+		return fmt.Errorf("加密配置失败: %w", err)
+	}
+
+	bundle := exportBundle{
+		Format:    bundleFormat,
+		Version:   bundleVersion,
+		CreatedAt: time.Now(),
+		KDF:       params,
+		Check:     check,
+		Data:      sealed,
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入导出文件失败: %w", err)
+	}
+
+	fmt.Printf("ExportBundle: 已导出 %d 个配置、%d 个密钥到 %s\n", len(configs), len(keys), filePath)
+	return nil
+}
+
+// withJumpConfigsNoLock 补充选中配置作为跳板机引用的其他配置（包括跳板机的跳板机），避免导入后引用失效
+func (s *Store) withJumpConfigsNoLock(configs []models.SSHConfig) []models.SSHConfig {
+	included := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		included[config.ID] = true
+	}
+
+	for i := 0; i < len(configs); i++ {
+		for _, jump := range configs[i].JumpHosts {
+			if jump.ConfigID == "" || included[jump.ConfigID] {
+				continue
+			}
+			for _, config := range s.configs {
+				if config.ID == jump.ConfigID {
+					configs = append(configs, config)
+					included[config.ID] = true
+					break
+				}
+			}
+		}
+	}
+	return configs
+}
+
+// referencedVaultKeysNoLock 收集配置及其跳板机引用的密钥库私钥
+func (s *Store) referencedVaultKeysNoLock(configs []models.SSHConfig) ([]vaultKey, error) {
+	var keys []vaultKey
+	seen := make(map[string]bool)
+	collect := func(id, name string) error {
+		if id == "" || seen[id] {
+			return nil
+		}
+		seen[id] = true
+		for _, key := range s.keys {
+			if key.ID == id {
+				keys = append(keys, key)
+				return nil
+			}
+		}
+		return fmt.Errorf("配置 %s 引用的密钥不存在: %s", name, id)
+	}
+
+	for _, config := range configs {
+		if err := collect(config.KeyID, config.Name); err != nil {
+			return nil, err
+		}
+		for _, jump := range config.JumpHosts {
+			if err := collect(jump.KeyID, config.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return keys, nil
+}
+
+// readBundle 读取并解密导出文件
+func readBundle(filePath, passphrase string) (*vaultData, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取导入文件失败: %w", err)
+	}
+
+	var bundle exportBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("导入文件格式错误: %w", err)
+	}
+	if bundle.Format != bundleFormat {
+		return nil, errors.New("不是 SSH MDZZ 导出文件")
+	}
+	if bundle.Version > bundleVersion {
+		return nil, fmt.Errorf("导出文件版本 %d 过新，请升级应用", bundle.Version)
+	}
+	if bundle.Version != bundleVersion {
+		return nil, fmt.Errorf("不支持的导出文件版本 %d", bundle.Version)
+	}
+
+	key, err := crypto.DeriveKey(passphrase, bundle.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if check, err := crypto.Decrypt(bundle.Check, key); err != nil || check != bundleCheckText {
+		return nil, errors.New("导出密码错误")
+	}
+
+	plaintext, err := crypto.Decrypt(bundle.Data, key)
+	if err != nil {
+		return nil, fmt.Errorf("解密导入文件失败: %w", err)
+	}
+	var vault vaultData
+	if err := json.Unmarshal([]byte(plaintext), &vault); err != nil {
+		return nil, fmt.Errorf("导入文件内容损坏: %w", err)
+	}
+	return &vault, nil
+}
+
+// ImportBundle 导入导出文件，strategy 指定与已有配置冲突时跳过、覆盖或同时保留
+func (s *Store) ImportBundle(filePath, passphrase, strategy string) (*models.ImportResult, error) {
+	switch strategy {
+	case ConflictSkip, ConflictOverwrite, ConflictKeepBoth:
+	default:
+		return nil, fmt.Errorf("未知的冲突处理方式: %s", strategy)
+	}
+
+	vault, err := readBundle(filePath, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	imported := vault.Configs
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userKey == "" {
+		return nil, errors.New("未设置加密密钥")
+	}
+
+	// 按导入顺序确定每个配置最终使用的 ID；导入文件中的 ID 可能为空或重复，按下标记录
+	newIDs := make([]string, len(imported))
+	actions := make([]string, len(imported))
+	targets := make([]int, len(imported))
+	usedIDs := make(map[string]bool, len(s.configs)+len(imported))
+	for _, config := range s.configs {
+		usedIDs[config.ID] = true
+	}
+	claimed := make(map[int]bool) // 已被前面的导入配置处理过的冲突目标
+	for i, config := range imported {
+		targets[i] = s.findConflictNoLock(config)
+		target := targets[i]
+		// 同一个已有配置只按策略处理一次，之后与它冲突的导入配置在覆盖或跳过策略下跳过
+		if target >= 0 && claimed[target] && strategy != ConflictKeepBoth {
+			actions[i] = "skip"
+			newIDs[i] = s.configs[target].ID
+			continue
+		}
+		if target >= 0 {
+			claimed[target] = true
+		}
+
+		switch {
+		case target < 0 || strategy == ConflictKeepBoth:
+			actions[i] = "add"
+			newIDs[i] = config.ID
+			if config.ID == "" || usedIDs[config.ID] {
+				newIDs[i] = GenerateID()
+			}
+			usedIDs[newIDs[i]] = true
+		case strategy == ConflictOverwrite:
+			actions[i] = "update"
+			newIDs[i] = s.configs[target].ID
+		default:
+			actions[i] = "skip"
+			newIDs[i] = s.configs[target].ID
+		}
+	}
+
+	// 跳板机引用按原 ID 指向导入文件中第一个使用该 ID 的配置
+	refIDs := make(map[string]string, len(imported))
+	for i, config := range imported {
+		if _, exists := refIDs[config.ID]; config.ID != "" && !exists {
+			refIDs[config.ID] = newIDs[i]
+		}
+	}
+
+	result := &models.ImportResult{}
+	now := time.Now()
+	importKey := s.vaultKeyImporterNoLock(vault.Keys, now)
+	for i, config := range imported {
+		config.ID = newIDs[i]
+		jumpHosts := make([]models.JumpHost, len(config.JumpHosts))
+		for j, jump := range config.JumpHosts {
+			if newID, ok := refIDs[jump.ConfigID]; ok {
+				jump.ConfigID = newID
+			}
+			if actions[i] != "skip" {
+				jump.KeyID = importKey(jump.KeyID)
+			}
+			jumpHosts[j] = jump
+		}
+		config.JumpHosts = jumpHosts
+		config.UpdatedAt = now
+		if actions[i] != "skip" {
+			config.KeyID = importKey(config.KeyID)
+		}
+
+		switch actions[i] {
+		case "add":
+			if targets[i] >= 0 {
+				config.Name += " (导入)"
+			}
+			config.CreatedAt = now
+			s.configs = append(s.configs, config)
+			result.Added++
+		case "update":
+			config.CreatedAt = s.configs[targets[i]].CreatedAt
+			s.configs[targets[i]] = config
+			result.Updated++
+		default:
+			result.Skipped++
+		}
+	}
+
+	if result.Added+result.Updated > 0 {
+		if err := s.saveConfigsNoLock(); err != nil {
+			// 保存失败时恢复内存中的配置
+			s.loadConfigsNoLock()
+			return nil, err
+		}
+	}
+
+	fmt.Printf("ImportBundle: 新增 %d，覆盖 %d，跳过 %d\n", result.Added, result.Updated, result.Skipped)
+	return result, nil
+}
+
+// vaultKeyImporterNoLock 返回把导入文件中的密钥 ID 映射为本机密钥 ID 的函数，
+// 相同指纹的密钥复用已有条目，否则在首次引用时以新 ID 加入密钥库
+func (s *Store) vaultKeyImporterNoLock(keys []vaultKey, now time.Time) func(string) string {
+	idMap := make(map[string]string, len(keys))
+	return func(id string) string {
+		if id == "" {
+			return id
+		}
+		if newID, ok := idMap[id]; ok {
+			return newID
+		}
+
+		for _, key := range keys {
+			if key.ID != id {
+				continue
+			}
+			for _, existing := range s.keys {
+				if existing.Fingerprint == key.Fingerprint {
+					idMap[id] = existing.ID
+					return existing.ID
+				}
+			}
+			key.ID = GenerateID()
+			key.CreatedAt = now
+			key.UsedBy = nil
+			s.keys = append(s.keys, key)
+			idMap[id] = key.ID
+			return key.ID
+		}
+
+		// 导入文件中没有该密钥，保留原引用
+		idMap[id] = id
+		return id
+	}
+}
+
+// findConflictNoLock 查找与导入配置冲突（ID 相同或主机+用户+端口相同）的已有配置
+func (s *Store) findConflictNoLock(config models.SSHConfig) int {
+	key := configKey(config.Host, config.Username, config.Port)
+	for i, existing := range s.configs {
+		if (config.ID != "" && existing.ID == config.ID) || configKey(existing.Host, existing.Username, existing.Port) == key {
+			return i
+		}
+	}
+	return -1
+}
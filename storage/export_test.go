@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ssh-mdzz/models"
+)
+
+// exportTestBundle 用给定的配置生成导出文件
+func exportTestBundle(t *testing.T, configs []models.SSHConfig) string {
+	t.Helper()
+
+	source := newTestStore(t, "source")
+	source.configs = configs
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	if err := source.ExportBundle(nil, path, "export-pass"); err != nil {
+		t.Fatalf("ExportBundle: %v", err)
+	}
+	return path
+}
+
+func TestExportBundleSealsHostnames(t *testing.T) {
+	path := exportTestBundle(t, testConfigs())
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"10.0.0.1", "root", "admin", "pw-a", "web"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("导出文件中出现明文 %q", secret)
+		}
+	}
+
+	if _, err := readBundle(path, "wrong"); err == nil {
+		t.Fatal("错误的导出密码应无法读取")
+	}
+	bundle, err := readBundle(path, "export-pass")
+	if err != nil {
+		t.Fatalf("readBundle: %v", err)
+	}
+	if len(bundle.Configs) != 2 || bundle.Configs[0].Host != "10.0.0.1" || bundle.Configs[0].Password != "pw-a" {
+		t.Fatalf("解密后的配置不正确: %+v", bundle.Configs)
+	}
+}
+
+func TestImportBundleIDs(t *testing.T) {
+	jump := models.SSHConfig{ID: "j", Name: "bastion", Host: "10.0.0.254", Port: "22", Username: "jump"}
+	target := models.SSHConfig{ID: "t", Name: "app", Host: "10.0.1.1", Port: "22", Username: "app",
+		JumpHosts: []models.JumpHost{{ConfigID: "j"}}}
+
+	tests := []struct {
+		name     string
+		existing []models.SSHConfig
+		bundle   []models.SSHConfig
+		strategy string
+		want     models.ImportResult
+		check    func(t *testing.T, configs []models.SSHConfig)
+	}{
+		{
+			name:     "跳板机引用指向新配置",
+			bundle:   []models.SSHConfig{jump, target},
+			strategy: ConflictSkip,
+			want:     models.ImportResult{Added: 2},
+			check: func(t *testing.T, configs []models.SSHConfig) {
+				if configs[1].JumpHosts[0].ConfigID != configs[0].ID {
+					t.Fatalf("跳板机引用为 %q，期望 %q", configs[1].JumpHosts[0].ConfigID, configs[0].ID)
+				}
+			},
+		},
+		{
+			name:     "同时保留时 ID 冲突的配置使用新 ID",
+			existing: []models.SSHConfig{{ID: "j", Name: "old", Host: "10.9.9.9", Port: "22", Username: "x"}},
+			bundle:   []models.SSHConfig{jump, target},
+			strategy: ConflictKeepBoth,
+			want:     models.ImportResult{Added: 2},
+			check: func(t *testing.T, configs []models.SSHConfig) {
+				if configs[1].ID == "j" || configs[1].Name != "bastion (导入)" {
+					t.Fatalf("导入的跳板机应使用新 ID: %+v", configs[1])
+				}
+				if configs[2].JumpHosts[0].ConfigID != configs[1].ID {
+					t.Fatalf("跳板机引用应指向导入的配置，实际为 %q", configs[2].JumpHosts[0].ConfigID)
+				}
+			},
+		},
+		{
+			name:     "覆盖时跳板机引用指向被覆盖的配置",
+			existing: []models.SSHConfig{{ID: "e", Name: "old bastion", Host: "10.0.0.254", Port: "22", Username: "jump"}},
+			bundle:   []models.SSHConfig{jump, target},
+			strategy: ConflictOverwrite,
+			want:     models.ImportResult{Added: 1, Updated: 1},
+			check: func(t *testing.T, configs []models.SSHConfig) {
+				if configs[0].ID != "e" || configs[0].Name != "bastion" {
+					t.Fatalf("已有配置应被覆盖并保留原 ID: %+v", configs[0])
+				}
+				if configs[1].JumpHosts[0].ConfigID != "e" {
+					t.Fatalf("跳板机引用为 %q，期望 e", configs[1].JumpHosts[0].ConfigID)
+				}
+			},
+		},
+		{
+			name: "重复和空 ID 按位置处理",
+			bundle: []models.SSHConfig{
+				{ID: "d", Name: "one", Host: "10.0.2.1", Port: "22", Username: "u"},
+				{ID: "d", Name: "two", Host: "10.0.2.2", Port: "22", Username: "u"},
+				{Name: "three", Host: "10.0.2.3", Port: "22", Username: "u"},
+				{Name: "four", Host: "10.0.2.4", Port: "22", Username: "u"},
+			},
+			strategy: ConflictSkip,
+			want:     models.ImportResult{Added: 4},
+			check: func(t *testing.T, configs []models.SSHConfig) {
+				seen := make(map[string]bool)
+				for _, config := range configs {
+					if config.ID == "" || seen[config.ID] {
+						t.Fatalf("导入后的 ID 为空或重复: %+v", configs)
+					}
+					seen[config.ID] = true
+				}
+			},
+		},
+		{
+			name:     "同一已有配置只覆盖一次",
+			existing: []models.SSHConfig{{ID: "e", Name: "old", Host: "10.0.3.1", Port: "22", Username: "u"}},
+			bundle: []models.SSHConfig{
+				{ID: "x", Name: "first", Host: "10.0.3.1", Port: "22", Username: "u"},
+				{ID: "y", Name: "second", Host: "10.0.3.1", Port: "22", Username: "u"},
+			},
+			strategy: ConflictOverwrite,
+			want:     models.ImportResult{Updated: 1, Skipped: 1},
+			check: func(t *testing.T, configs []models.SSHConfig) {
+				if len(configs) != 1 || configs[0].Name != "first" {
+					t.Fatalf("应只用第一个冲突的配置覆盖: %+v", configs)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := exportTestBundle(t, tt.bundle)
+
+			s := newTestStore(t, "secret")
+			s.configs = append([]models.SSHConfig{}, tt.existing...)
+			result, err := s.ImportBundle(path, "export-pass", tt.strategy)
+			if err != nil {
+				t.Fatalf("ImportBundle: %v", err)
+			}
+			if *result != tt.want {
+				t.Fatalf("导入结果为 %+v，期望 %+v", *result, tt.want)
+			}
+			tt.check(t, s.configs)
+		})
+	}
+}
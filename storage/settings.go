@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"ssh-mdzz/fileutil"
+	"ssh-mdzz/models"
+)
+
+// LoadSettings 读取应用设置，文件不存在时返回默认设置
+// 设置不含敏感信息，明文保存，解锁配置前即可读取
+func (s *Store) LoadSettings() (models.AppSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.loadSettingsNoLock()
+}
+
+func (s *Store) loadSettingsNoLock() (models.AppSettings, error) {
+	var settings models.AppSettings
+	data, err := os.ReadFile(s.settingsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return settings, err
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("设置文件格式错误: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdateSettings 读取当前设置，由 update 修改后保存
+func (s *Store) UpdateSettings(update func(*models.AppSettings)) (models.AppSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, err := s.loadSettingsNoLock()
+	if err != nil {
+		return settings, err
+	}
+	update(&settings)
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return settings, err
+	}
+	if err := fileutil.WriteFileAtomic(s.settingsPath, data, 0600); err != nil {
+		return settings, fmt.Errorf("保存设置失败: %w", err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ssh-mdzz/models"
+)
+
+// maxIncludeDepth Include 最大嵌套层数，防止循环引用
+const maxIncludeDepth = 16
+
+// sshConfigBlock ~/.ssh/config 中的一个 Host 块
+type sshConfigBlock struct {
+	patterns []string            // 为 nil 表示 Match 块，不参与匹配
+	options  map[string][]string // 选项名（小写）→ 参数
+	order    []string            // 选项出现顺序
+}
+
+// set 记录选项，同一块内只保留第一次出现的值（IdentityFile 除外）
+func (b *sshConfigBlock) set(key string, args []string) {
+	if _, exists := b.options[key]; exists && key != "identityfile" {
+		return
+	}
+	if _, exists := b.options[key]; !exists {
+		b.order = append(b.order, key)
+	}
+	b.options[key] = append(b.options[key], args...)
+}
+
+// matches 判断 Host 块是否适用于别名（支持 * ? 通配符和 ! 取反）
+func (b *sshConfigBlock) matches(alias string) bool {
+	matched := false
+	for _, pattern := range b.patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		ok, _ := path.Match(pattern, alias)
+		if !ok {
+			continue
+		}
+		if negate {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+func newSSHConfigBlock(patterns []string) *sshConfigBlock {
+	return &sshConfigBlock{patterns: patterns, options: make(map[string][]string)}
+}
+
+// DefaultSSHConfigPath 返回 ~/.ssh/config 路径
+func DefaultSSHConfigPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ssh", "config")
+}
+
+// parseSSHConfig 解析 OpenSSH 配置文件，Include 的内容按位置展开
+func parseSSHConfig(configPath string) ([]*sshConfigBlock, error) {
+	// 文件开头、第一个 Host 之前的选项对所有主机生效
+	global := newSSHConfigBlock([]string{"*"})
+	blocks := []*sshConfigBlock{global}
+
+	if err := parseSSHConfigFile(configPath, &blocks, global, 0); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func parseSSHConfigFile(configPath string, blocks *[]*sshConfigBlock, current *sshConfigBlock, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("Include 嵌套过深: %s", configPath)
+	}
+
+	f, err := os.Open(configPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		key, args := splitSSHConfigLine(scanner.Text())
+		if key == "" {
+			continue
+		}
+
+		switch key {
+		case "host":
+			current = newSSHConfigBlock(args)
+			*blocks = append(*blocks, current)
+		case "match":
+			// 不支持 Match 条件，其下的选项全部忽略
+			current = newSSHConfigBlock(nil)
+			*blocks = append(*blocks, current)
+		case "include":
+			for _, pattern := range args {
+				files, err := filepath.Glob(expandSSHPath(pattern, filepath.Join(userHomeDir(), ".ssh")))
+				if err != nil {
+					return fmt.Errorf("%s:%d: Include 路径无效: %w", configPath, lineNum, err)
+				}
+				for _, file := range files {
+					// 被包含文件中第一个 Host 之前的选项属于当前块
+					included := newSSHConfigBlock(current.patterns)
+					*blocks = append(*blocks, included)
+					if err := parseSSHConfigFile(file, blocks, included, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+			// Include 之后的选项仍属于原来的块
+			continuation := newSSHConfigBlock(current.patterns)
+			*blocks = append(*blocks, continuation)
+			current = continuation
+		default:
+			current.set(key, args)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// splitSSHConfigLine 拆分一行配置为小写的选项名和参数（支持 key=value 和引号）
+func splitSSHConfigLine(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+
+	// 选项名与参数之间可以用空白或 = 分隔
+	sep := strings.IndexAny(line, " \t=")
+	if sep < 0 {
+		return strings.ToLower(line), nil
+	}
+	key := strings.ToLower(line[:sep])
+	rest := strings.TrimLeft(line[sep:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+
+	var args []string
+	var buf strings.Builder
+	inQuote := false
+	for _, r := range rest {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case (r == ' ' || r == '\t') && !inQuote:
+			if buf.Len() > 0 {
+				args = append(args, buf.String())
+				buf.Reset()
+			}
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	if buf.Len() > 0 {
+		args = append(args, buf.String())
+	}
+
+	return key, args
+}
+
+// resolveSSHHost 按 OpenSSH 规则（先出现的值优先）计算别名的最终选项
+func resolveSSHHost(blocks []*sshConfigBlock, alias string) map[string][]string {
+	resolved := make(map[string][]string)
+	for _, block := range blocks {
+		if !block.matches(alias) {
+			continue
+		}
+		for _, key := range block.order {
+			if _, exists := resolved[key]; exists && key != "identityfile" {
+				continue
+			}
+			resolved[key] = append(resolved[key], block.options[key]...)
+		}
+	}
+	return resolved
+}
+
+// sshHostAliases 列出配置中所有具体的主机别名（不含通配符和取反模式）
+func sshHostAliases(blocks []*sshConfigBlock) []string {
+	seen := make(map[string]bool)
+	var aliases []string
+	for _, block := range blocks {
+		for _, pattern := range block.patterns {
+			if strings.ContainsAny(pattern, "*?!") || seen[pattern] {
+				continue
+			}
+			seen[pattern] = true
+			aliases = append(aliases, pattern)
+		}
+	}
+	return aliases
+}
+
+// sshHostConfig 将别名的选项转换为 SSH 配置
+func sshHostConfig(alias string, options map[string][]string) models.SSHConfig {
+	config := models.SSHConfig{
+		Name:         alias,
+		Host:         alias,
+		Port:         "22",
+		Username:     localUsername(),
+		TransferMode: "sftp",
+	}
+
+	if values := options["hostname"]; len(values) > 0 {
+		config.Host = strings.ReplaceAll(values[0], "%h", alias)
+	}
+	if values := options["port"]; len(values) > 0 {
+		config.Port = values[0]
+	}
+	if values := options["user"]; len(values) > 0 {
+		config.Username = values[0]
+	}
+	if values := options["identityfile"]; len(values) > 0 {
+		config.KeyPath = expandSSHPath(strings.ReplaceAll(values[0], "%h", config.Host), userHomeDir())
+		config.AuthType = "key"
+	}
+	return config
+}
+
+// parseProxyJump 解析 ProxyJump 中的 [user@]host[:port]
+func parseProxyJump(spec string) (username, host, port string) {
+	if at := strings.LastIndex(spec, "@"); at >= 0 {
+		username = spec[:at]
+		spec = spec[at+1:]
+	}
+
+	if h, p, err := net.SplitHostPort(spec); err == nil {
+		return username, h, p
+	}
+	return username, strings.Trim(spec, "[]"), ""
+}
+
+// configKey 去重使用的主机+用户+端口
+func configKey(host, username, port string) string {
+	if port == "" {
+		port = "22"
+	}
+	return strings.ToLower(host) + "|" + username + "|" + port
+}
+
+// PreviewSSHConfigImport 解析 OpenSSH 配置，返回每个主机将被新增、更新还是跳过
+func (s *Store) PreviewSSHConfigImport(configPath string) ([]models.ImportPreviewItem, error) {
+	if configPath == "" {
+		configPath = DefaultSSHConfigPath()
+	}
+
+	blocks, err := parseSSHConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", configPath, err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.previewSSHConfigImportNoLock(blocks), nil
+}
+
+// previewSSHConfigImportNoLock 对照已有配置计算每个主机的导入动作
+func (s *Store) previewSSHConfigImportNoLock(blocks []*sshConfigBlock) []models.ImportPreviewItem {
+	existing := make(map[string]models.SSHConfig)
+	for _, config := range s.configs {
+		existing[configKey(config.Host, config.Username, config.Port)] = config
+	}
+
+	// 先计算所有别名的配置，供 ProxyJump 引用
+	aliases := sshHostAliases(blocks)
+	resolved := make(map[string]models.SSHConfig, len(aliases))
+	proxyJumps := make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		options := resolveSSHHost(blocks, alias)
+		resolved[alias] = sshHostConfig(alias, options)
+		if values := options["proxyjump"]; len(values) > 0 {
+			proxyJumps[alias] = values[0]
+		}
+	}
+
+	seen := make(map[string]string)
+	items := make([]models.ImportPreviewItem, 0, len(aliases))
+	for _, alias := range aliases {
+		config := resolved[alias]
+		config.JumpHosts = s.importJumpHosts(proxyJumps[alias], resolved, existing)
+
+		item := models.ImportPreviewItem{Alias: alias, Config: config}
+		key := configKey(config.Host, config.Username, config.Port)
+
+		if config.Host == "" || config.Username == "" {
+			item.Action = "skip"
+			item.Reason = "缺少主机名或用户名"
+		} else if first, dup := seen[key]; dup {
+			item.Action = "skip"
+			item.Reason = fmt.Sprintf("与 %s 的主机、用户和端口相同", first)
+		} else if current, exists := existing[key]; exists {
+			item.ExistingID = current.ID
+			merged := mergeImportedConfig(current, config)
+			if sameImportFields(current, merged) {
+				item.Action = "skip"
+				item.Reason = fmt.Sprintf("已存在相同的配置 %s", current.Name)
+			} else {
+				item.Action = "update"
+				item.Reason = fmt.Sprintf("更新已有配置 %s 的密钥或跳板机", current.Name)
+			}
+			item.Config = merged
+		} else {
+			item.Action = "add"
+		}
+
+		seen[key] = alias
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// importJumpHosts 将 ProxyJump 转换为跳板机链，已保存的主机按 ID 引用
+func (s *Store) importJumpHosts(proxyJump string, resolved map[string]models.SSHConfig, existing map[string]models.SSHConfig) []models.JumpHost {
+	if proxyJump == "" || strings.EqualFold(proxyJump, "none") {
+		return nil
+	}
+
+	var jumpHosts []models.JumpHost
+	for _, spec := range strings.Split(proxyJump, ",") {
+		username, host, port := parseProxyJump(strings.TrimSpace(spec))
+
+		jump := models.JumpHost{Host: host, Port: port, Username: username}
+		// 跳板机是配置文件中的别名时使用其 HostName、User、Port 和密钥
+		if aliasConfig, ok := resolved[host]; ok {
+			jump.Host = aliasConfig.Host
+			if jump.Port == "" {
+				jump.Port = aliasConfig.Port
+			}
+			if jump.Username == "" {
+				jump.Username = aliasConfig.Username
+			}
+			jump.KeyPath = aliasConfig.KeyPath
+			jump.AuthType = aliasConfig.AuthType
+		}
+		if jump.Port == "" {
+			jump.Port = "22"
+		}
+		if jump.Username == "" {
+			jump.Username = localUsername()
+		}
+
+		if saved, ok := existing[configKey(jump.Host, jump.Username, jump.Port)]; ok {
+			jump = models.JumpHost{ConfigID: saved.ID}
+		}
+		jumpHosts = append(jumpHosts, jump)
+	}
+	return jumpHosts
+}
+
+// mergeImportedConfig 用导入的密钥和跳板机更新已有配置，保留名称、密码等其余信息
+func mergeImportedConfig(current, imported models.SSHConfig) models.SSHConfig {
+	merged := current
+	if imported.KeyPath != "" {
+		merged.KeyPath = imported.KeyPath
+		if merged.AuthType == "" || merged.AuthType == "password" {
+			merged.AuthType = imported.AuthType
+		}
+	}
+	if len(imported.JumpHosts) > 0 {
+		merged.JumpHosts = imported.JumpHosts
+	}
+	return merged
+}
+
+// sameImportFields 比较导入会修改的字段
+func sameImportFields(a, b models.SSHConfig) bool {
+	if a.KeyPath != b.KeyPath || a.AuthType != b.AuthType || len(a.JumpHosts) != len(b.JumpHosts) {
+		return false
+	}
+	for i := range a.JumpHosts {
+		if a.JumpHosts[i] != b.JumpHosts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ImportSSHConfig 导入预览中选中的主机，aliases 为空时导入所有可导入的主机
+func (s *Store) ImportSSHConfig(configPath string, aliases []string) (*models.ImportResult, error) {
+	if configPath == "" {
+		configPath = DefaultSSHConfigPath()
+	}
+
+	blocks, err := parseSSHConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", configPath, err)
+	}
+
+	// 所有修改在同一把锁内完成并只保存一次，避免逐个保存挤掉导入前的备份
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userKey == "" {
+		return nil, errors.New("未设置加密密钥")
+	}
+
+	items := s.previewSSHConfigImportNoLock(blocks)
+	prevConfigs := append([]models.SSHConfig(nil), s.configs...)
+
+	selected := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		selected[alias] = true
+	}
+
+	result := &models.ImportResult{}
+	now := time.Now()
+	for _, item := range items {
+		if len(selected) > 0 && !selected[item.Alias] {
+			continue
+		}
+
+		switch item.Action {
+		case "add":
+			config := item.Config
+			config.ID = GenerateID()
+			config.CreatedAt = now
+			config.UpdatedAt = now
+			s.configs = append(s.configs, config)
+			result.Added++
+		case "update":
+			config := item.Config
+			config.UpdatedAt = now
+			for i := range s.configs {
+				if s.configs[i].ID == config.ID {
+					s.configs[i] = config
+					break
+				}
+			}
+			result.Updated++
+		default:
+			result.Skipped++
+		}
+	}
+
+	if result.Added+result.Updated > 0 {
+		if err := s.saveConfigsNoLock(); err != nil {
+			s.configs = prevConfigs
+			return nil, fmt.Errorf("保存导入的配置失败: %w", err)
+		}
+	}
+
+	fmt.Printf("ImportSSHConfig: 新增 %d，更新 %d，跳过 %d\n", result.Added, result.Updated, result.Skipped)
+	return result, nil
+}
+
+// expandSSHPath 展开 ~ 和 %d，相对路径以 baseDir 为基准
+func expandSSHPath(p, baseDir string) string {
+	home := userHomeDir()
+	p = strings.ReplaceAll(p, "%d", home)
+	if p == "~" {
+		return home
+	}
+	if strings.HasPrefix(p, "~/") {
+		return filepath.Join(home, p[2:])
+	}
+	if !filepath.IsAbs(p) {
+		return filepath.Join(baseDir, p)
+	}
+	return p
+}
+
+func userHomeDir() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// localUsername 本机用户名，作为未指定 User 时的默认值
+func localUsername() string {
+	if u, err := user.Current(); err == nil {
+		// Windows 下为 DOMAIN\user
+		if i := strings.LastIndex(u.Username, `\`); i >= 0 {
+			return u.Username[i+1:]
+		}
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitSSHConfigLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		wantKey  string
+		wantArgs []string
+	}{
+		{"", "", nil},
+		{"   # 注释", "", nil},
+		{"HostName example.com", "hostname", []string{"example.com"}},
+		{"\tPort=2222", "port", []string{"2222"}},
+		{"Port = 2222", "port", []string{"2222"}},
+		{"Host web db\t*.prod", "host", []string{"web", "db", "*.prod"}},
+		{`IdentityFile "~/My Keys/id_ed25519"`, "identityfile", []string{"~/My Keys/id_ed25519"}},
+		{"Compression", "compression", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			key, args := splitSSHConfigLine(tt.line)
+			if key != tt.wantKey || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Fatalf("splitSSHConfigLine(%q) = %q %q，期望 %q %q", tt.line, key, args, tt.wantKey, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSSHConfigBlockMatches(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		alias    string
+		want     bool
+	}{
+		{[]string{"web"}, "web", true},
+		{[]string{"web"}, "web2", false},
+		{[]string{"web*"}, "web2", true},
+		{[]string{"db?"}, "db1", true},
+		{[]string{"*", "!bastion"}, "bastion", false},
+		{[]string{"!bastion", "*"}, "bastion", false},
+		{[]string{"*", "!bastion"}, "web", true},
+		{[]string{"!bastion"}, "web", false},
+		{nil, "web", false}, // Match 块
+	}
+
+	for _, tt := range tests {
+		block := newSSHConfigBlock(tt.patterns)
+		if got := block.matches(tt.alias); got != tt.want {
+			t.Errorf("%v 匹配 %q = %v，期望 %v", tt.patterns, tt.alias, got, tt.want)
+		}
+	}
+}
+
+func TestParseSSHConfig(t *testing.T) {
+	dir := t.TempDir()
+	included := filepath.Join(dir, "included")
+	if err := os.WriteFile(included, []byte(`
+User included-user
+Host inc
+  HostName inc.example.com
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config")
+	if err := os.WriteFile(configPath, []byte(`
+# 全局选项
+IdentityFile ~/.ssh/global_key
+
+Host web web-alias
+  HostName %h.example.com
+  Port 2222
+  Port 3333
+  IdentityFile ~/.ssh/web_key
+
+Host db
+  Include `+included+`
+  HostName db.internal
+  ProxyJump admin@bastion:2200
+
+Match host *
+  User ignored
+
+Host *
+  User default-user
+  Port 22
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := parseSSHConfig(configPath)
+	if err != nil {
+		t.Fatalf("parseSSHConfig: %v", err)
+	}
+
+	if got, want := sshHostAliases(blocks), []string{"web", "web-alias", "db", "inc"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sshHostAliases = %v，期望 %v", got, want)
+	}
+
+	tests := []struct {
+		alias string
+		want  map[string][]string
+	}{
+		{"web", map[string][]string{
+			"identityfile": {"~/.ssh/global_key", "~/.ssh/web_key"},
+			"hostname":     {"%h.example.com"},
+			"port":         {"2222"}, // 同一选项先出现的值优先
+			"user":         {"default-user"},
+		}},
+		{"db", map[string][]string{
+			"identityfile": {"~/.ssh/global_key"},
+			"user":         {"included-user"}, // 被包含文件中第一个 Host 之前的选项属于 db
+			"hostname":     {"db.internal"},
+			"proxyjump":    {"admin@bastion:2200"},
+			"port":         {"22"},
+		}},
+		{"inc", map[string][]string{
+			"identityfile": {"~/.ssh/global_key"},
+			"hostname":     {"inc.example.com"},
+			"user":         {"default-user"},
+			"port":         {"22"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			if got := resolveSSHHost(blocks, tt.alias); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("resolveSSHHost(%q) = %v，期望 %v", tt.alias, got, tt.want)
+			}
+		})
+	}
+
+	config := sshHostConfig("web", resolveSSHHost(blocks, "web"))
+	if config.Host != "web.example.com" || config.Port != "2222" || config.Username != "default-user" || config.AuthType != "key" {
+		t.Fatalf("sshHostConfig 结果不正确: %+v", config)
+	}
+}
+
+func TestParseSSHConfigIncludeLoop(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configPath, []byte("Include "+configPath+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseSSHConfig(configPath); err == nil {
+		t.Fatal("循环 Include 应返回错误")
+	}
+}
+
+func TestParseProxyJump(t *testing.T) {
+	tests := []struct {
+		spec                 string
+		user, host, wantPort string
+	}{
+		{"bastion", "", "bastion", ""},
+		{"admin@bastion", "admin", "bastion", ""},
+		{"admin@bastion:2200", "admin", "bastion", "2200"},
+		{"[::1]:2200", "", "::1", "2200"},
+		{"[fe80::1]", "", "fe80::1", ""},
+		{"a@b@host", "a@b", "host", ""},
+	}
+
+	for _, tt := range tests {
+		user, host, port := parseProxyJump(tt.spec)
+		if user != tt.user || host != tt.host || port != tt.wantPort {
+			t.Errorf("parseProxyJump(%q) = %q %q %q，期望 %q %q %q", tt.spec, user, host, port, tt.user, tt.host, tt.wantPort)
+		}
+	}
+}
@@ -1,44 +1,110 @@
 package storage
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"ssh-mdzz/crypto"
+	"ssh-mdzz/fileutil"
 	"ssh-mdzz/models"
 )
 
 type Store struct {
-	configs     []models.SSHConfig
-	userKey     string
-	filePath    string
-	sessionPath string
-	mu          sync.RWMutex
+	configs      []models.SSHConfig
+	keys         []vaultKey // 密钥库中的私钥，与配置一起加密保存
+	userKey      string
+	kdf          crypto.KDFParams // 当前配置文件的密钥派生参数
+	derivedKey   []byte           // 由 userKey 和 kdf 派生的加密密钥
+	filePath     string
+	sessionPath  string
+	settingsPath string
+	mu           sync.RWMutex
+}
+
+const (
+	// storeFileVersion 配置文件格式版本：配置和密钥库加密为一个密文
+	storeFileVersion = 1
+	// keyCheckText 用于校验密钥是否正确
+	keyCheckText = "ssh-mdzz-key-check"
+)
+
+// ErrInvalidKey 密钥无法通过配置文件的校验值
+var ErrInvalidKey = errors.New("密钥错误")
+
+// vaultData 配置文件中加密保存的内容
+type vaultData struct {
+	Configs []models.SSHConfig `json:"configs"`
+	Keys    []vaultKey         `json:"keys,omitempty"`
+}
+
+// storeFile 配置文件结构，明文头部只包含格式版本和密钥派生参数
+type storeFile struct {
+	Version int              `json:"version"`
+	KDF     crypto.KDFParams `json:"kdf"`
+	Check   string           `json:"check"` // 加密的校验文本
+	Data    string           `json:"data"`  // 加密的 vaultData
 }
 
 // SessionData 会话数据结构
 type SessionData struct {
-	Token            string    `json:"token"`
-	KeyHash          string    `json:"keyHash"`
-	CreatedAt        time.Time `json:"createdAt"`
-	ExpiresAt        time.Time `json:"expiresAt"`
-	EncryptedTempKey string    `json:"encryptedTempKey,omitempty"` // 加密的临时密钥
+	Token            string            `json:"token"`
+	KeyKDF           *crypto.KDFParams `json:"keyKdf,omitempty"`      // 计算密钥校验值的派生参数
+	KeyVerifier      string            `json:"keyVerifier,omitempty"` // 加盐的密钥校验值
+	KeyHash          string            `json:"keyHash,omitempty"`     // 旧版本的无盐密钥哈希，验证通过后移除
+	CreatedAt        time.Time         `json:"createdAt"`
+	ExpiresAt        time.Time         `json:"expiresAt"`
+	EncryptedTempKey string            `json:"encryptedTempKey,omitempty"` // 加密的临时密钥
+}
+
+// setKey 用新的盐计算密钥校验值，并移除旧版本的无盐哈希
+func (d *SessionData) setKey(key string) error {
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		return err
+	}
+	verifier, err := crypto.KeyVerifier(key, params)
+	if err != nil {
+		return err
+	}
+
+	d.KeyKDF = &params
+	d.KeyVerifier = verifier
+	d.KeyHash = ""
+	return nil
+}
+
+// matchesKey 检查密钥是否与会话中的校验值匹配，旧会话使用无盐哈希比较
+func (d *SessionData) matchesKey(key string) bool {
+	if d.KeyVerifier != "" && d.KeyKDF != nil {
+		ok, err := crypto.CheckKeyVerifier(key, *d.KeyKDF, d.KeyVerifier)
+		return err == nil && ok
+	}
+	if d.KeyHash != "" {
+		return subtle.ConstantTimeCompare([]byte(d.KeyHash), []byte(crypto.LegacyKeyHash(key))) == 1
+	}
+	return false
 }
 
 func NewStore() *Store {
 	home, _ := os.UserHomeDir()
 	return &Store{
-		configs:     []models.SSHConfig{},
-		filePath:    filepath.Join(home, ".ssh-mdzz-configs.enc"),
-		sessionPath: filepath.Join(home, ".ssh-mdzz-session.json"),
+		configs:      []models.SSHConfig{},
+		filePath:     filepath.Join(home, ".ssh-mdzz-configs.enc"),
+		sessionPath:  filepath.Join(home, ".ssh-mdzz-session.json"),
+		settingsPath: filepath.Join(home, ".ssh-mdzz-settings.json"),
 	}
 }
 
@@ -48,6 +114,7 @@ func (s *Store) SetUserKey(key string) error {
 	defer s.mu.Unlock()
 
 	s.userKey = key
+	s.derivedKey = nil
 
 	// 如果已有配置文件，尝试用新密钥加载
 	if _, err := os.Stat(s.filePath); err == nil {
@@ -91,28 +158,156 @@ func (s *Store) loadConfigsNoLock() error {
 	if err != nil {
 		if os.IsNotExist(err) {
 			s.configs = []models.SSHConfig{}
+			s.keys = nil
 			return nil
 		}
 		return err
 	}
 
+	vault, migrate, err := s.decodeVaultNoLock(data)
+	if err != nil {
+		return err
+	}
+
+	s.configs = vault.Configs
+	s.keys = vault.Keys
+	if migrate {
+		// 旧格式文件解密成功后立即以当前格式重新保存，不把旧格式文件留作备份
+		fmt.Printf("loadConfigsNoLock: 迁移旧格式配置文件（%d 个配置）\n", len(vault.Configs))
+		if err := s.writeConfigsNoLock(false); err != nil {
+			return err
+		}
+		s.upgradeBackupsNoLock()
+	}
+	return nil
+}
+
+// decodeVaultNoLock 用当前密钥解密配置文件内容，旧格式文件返回 migrate=true
+func (s *Store) decodeVaultNoLock(data []byte) (vault *vaultData, migrate bool, err error) {
+	// 旧版本文件是直接保存的配置数组
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		configs, err := s.decodeLegacyNoLock(trimmed)
+		if err != nil {
+			return nil, false, err
+		}
+		return &vaultData{Configs: configs}, true, nil
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, false, err
+	}
+	if file.Version > storeFileVersion {
+		return nil, false, fmt.Errorf("配置文件版本 %d 过新，请升级应用", file.Version)
+	}
+
+	key, err := s.deriveKeyNoLock(file.KDF)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// 先校验密钥，避免把解密失败误报为文件损坏
+	if check, err := crypto.Decrypt(file.Check, key); err != nil || check != keyCheckText {
+		s.derivedKey = nil
+		return nil, false, ErrInvalidKey
+	}
+
+	plaintext, err := crypto.Decrypt(file.Data, key)
+	if err != nil {
+		return nil, false, fmt.Errorf("解密配置失败: %w", err)
+	}
+
+	vault = &vaultData{Configs: []models.SSHConfig{}}
+	if err := json.Unmarshal([]byte(plaintext), vault); err != nil {
+		return nil, false, fmt.Errorf("配置文件内容损坏: %w", err)
+	}
+	if vault.Configs == nil {
+		vault.Configs = []models.SSHConfig{}
+	}
+	return vault, false, nil
+}
+
+// decodeLegacyNoLock 解密旧版本（无盐 SHA-256 派生密钥）的配置文件，重新保存时会生成新的派生参数
+func (s *Store) decodeLegacyNoLock(data []byte) ([]models.SSHConfig, error) {
 	var encryptedConfigs []models.SSHConfig
 	if err := json.Unmarshal(data, &encryptedConfigs); err != nil {
-		return err
+		return nil, err
 	}
 
-	// 解密配置
-	s.configs = make([]models.SSHConfig, 0, len(encryptedConfigs))
+	decrypted := 0
+	configs := make([]models.SSHConfig, 0, len(encryptedConfigs))
 	for _, config := range encryptedConfigs {
-		if err := crypto.DecryptConfig(&config, s.userKey); err != nil {
-			return err
+		if hasEncryptedFields(&config) {
+			decrypted++
+		}
+		if err := crypto.DecryptConfigLegacy(&config, s.userKey); err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+
+	if decrypted == 0 {
+		if err := s.checkKeyWithSessionNoLock(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.kdf = crypto.KDFParams{}
+	s.derivedKey = nil
+	return configs, nil
+}
+
+// hasEncryptedFields 检查旧格式配置中是否有逐字段加密的密文
+func hasEncryptedFields(config *models.SSHConfig) bool {
+	if config.Password != "" || config.KeyPassphrase != "" {
+		return true
+	}
+	for _, jump := range config.JumpHosts {
+		if jump.Password != "" || jump.KeyPassphrase != "" {
+			return true
 		}
-		s.configs = append(s.configs, config)
 	}
+	return false
+}
 
+// checkKeyWithSessionNoLock 旧格式文件中没有可用于校验的密文时，用会话中的密钥校验值防止以错误密钥迁移
+func (s *Store) checkKeyWithSessionNoLock() error {
+	sessionData, err := s.readSessionNoLock()
+	if err != nil {
+		// 没有会话时无从校验，与未加密的旧文件一样直接迁移
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取会话失败，无法校验密钥: %w", err)
+	}
+	if !sessionData.matchesKey(s.userKey) {
+		return ErrInvalidKey
+	}
 	return nil
 }
 
+// deriveKeyNoLock 按参数派生加密密钥，参数未变化时复用已派生的密钥
+func (s *Store) deriveKeyNoLock(params crypto.KDFParams) ([]byte, error) {
+	if s.derivedKey != nil && sameKDFParams(s.kdf, params) {
+		return s.derivedKey, nil
+	}
+
+	key, err := crypto.DeriveKey(s.userKey, params)
+	if err != nil {
+		return nil, err
+	}
+
+	s.kdf = params
+	s.derivedKey = key
+	return key, nil
+}
+
+// sameKDFParams 比较两组密钥派生参数
+func sameKDFParams(a, b crypto.KDFParams) bool {
+	return a.Algorithm == b.Algorithm && a.Time == b.Time && a.Memory == b.Memory &&
+		a.Threads == b.Threads && bytes.Equal(a.Salt, b.Salt)
+}
+
 // LoadConfigs 加载所有配置
 func (s *Store) LoadConfigs() ([]models.SSHConfig, error) {
 	s.mu.Lock()
@@ -139,6 +334,11 @@ func (s *Store) SaveConfigs() error {
 
 // saveConfigsNoLock 保存所有配置（内部方法，不加锁）
 func (s *Store) saveConfigsNoLock() error {
+	return s.writeConfigsNoLock(true)
+}
+
+// writeConfigsNoLock 加密并写入配置文件，backup 为 true 时先备份当前文件
+func (s *Store) writeConfigsNoLock(backup bool) error {
 	fmt.Printf("saveConfigsNoLock: 开始保存配置\n")
 
 	if s.userKey == "" {
@@ -146,28 +346,38 @@ func (s *Store) saveConfigsNoLock() error {
 		return errors.New("未设置加密密钥")
 	}
 
-	fmt.Printf("saveConfigsNoLock: 开始加密 %d 个配置\n", len(s.configs))
-
-	// 加密配置
-	encryptedConfigs := make([]models.SSHConfig, len(s.configs))
-	for i, config := range s.configs {
-		configCopy := config
-		if err := crypto.EncryptConfig(&configCopy, s.userKey); err != nil {
-			fmt.Printf("saveConfigsNoLock: 加密配置失败: %v\n", err)
+	// 新文件生成独立的盐
+	if len(s.kdf.Salt) == 0 {
+		params, err := crypto.NewKDFParams()
+		if err != nil {
 			return err
 		}
-		encryptedConfigs[i] = configCopy
+		s.kdf = params
+		s.derivedKey = nil
 	}
 
-	fmt.Printf("saveConfigsNoLock: 开始序列化配置\n")
-	data, err := json.MarshalIndent(encryptedConfigs, "", "  ")
+	key, err := s.deriveKeyNoLock(s.kdf)
 	if err != nil {
-		fmt.Printf("saveConfigsNoLock: 序列化失败: %v\n", err)
+		fmt.Printf("saveConfigsNoLock: 派生密钥失败: %v\n", err)
 		return err
 	}
 
+	fmt.Printf("saveConfigsNoLock: 开始加密 %d 个配置\n", len(s.configs))
+	data, err := encodeVault(&vaultData{Configs: s.configs, Keys: s.keys}, s.kdf, key)
+	if err != nil {
+		fmt.Printf("saveConfigsNoLock: 加密配置失败: %v\n", err)
+		return err
+	}
+
+	// 覆盖前备份当前文件，备份失败不影响保存
+	if backup {
+		if err := s.backupNoLock(); err != nil {
+			fmt.Printf("saveConfigsNoLock: 备份配置文件失败: %v\n", err)
+		}
+	}
+
 	fmt.Printf("saveConfigsNoLock: 开始写入文件 %s\n", s.filePath)
-	err = os.WriteFile(s.filePath, data, 0600)
+	err = fileutil.WriteFileAtomic(s.filePath, data, 0600)
 	if err != nil {
 		fmt.Printf("saveConfigsNoLock: 写入文件失败: %v\n", err)
 	} else {
@@ -177,6 +387,126 @@ func (s *Store) saveConfigsNoLock() error {
 	return err
 }
 
+// encodeVault 加密配置和密钥库，生成配置文件内容
+func encodeVault(vault *vaultData, params crypto.KDFParams, key []byte) ([]byte, error) {
+	plaintext, err := json.Marshal(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := crypto.Encrypt(keyCheckText, key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := crypto.Encrypt(string(plaintext), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(storeFile{
+		Version: storeFileVersion,
+		KDF:     params,
+		Check:   check,
+		Data:    sealed,
+	}, "", "  ")
+}
+
+// ChangeUserKey 更换用户加密密钥，使用新密钥和新的盐重新加密所有配置
+func (s *Store) ChangeUserKey(oldKey, newKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if newKey == "" {
+		return errors.New("新密钥不能为空")
+	}
+	if oldKey == newKey {
+		return errors.New("新密钥不能与原密钥相同")
+	}
+	if s.userKey != "" && oldKey != s.userKey {
+		return errors.New("原密钥错误")
+	}
+
+	// 保存当前状态，任何一步失败都恢复
+	prevKey, prevKDF, prevDerived := s.userKey, s.kdf, s.derivedKey
+	prevConfigs, prevKeys := s.configs, s.keys
+	restore := func() {
+		s.userKey, s.kdf, s.derivedKey = prevKey, prevKDF, prevDerived
+		s.configs, s.keys = prevConfigs, prevKeys
+	}
+
+	// 用原密钥重新读取文件，确认所有配置都能解密
+	s.userKey = oldKey
+	s.derivedKey = nil
+	if err := s.loadConfigsNoLock(); err != nil {
+		restore()
+		return fmt.Errorf("原密钥错误: %w", err)
+	}
+
+	verifiedKey, verifiedKDF, verifiedDerived := s.userKey, s.kdf, s.derivedKey
+
+	// 备份仍使用原密钥加密，先用原密钥读出，换密钥后重新加密
+	backups, err := s.readBackupsNoLock()
+	s.userKey, s.kdf, s.derivedKey = verifiedKey, verifiedKDF, verifiedDerived
+	if err != nil {
+		restore()
+		return fmt.Errorf("读取备份失败: %w", err)
+	}
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		restore()
+		return err
+	}
+
+	s.userKey = newKey
+	s.kdf = params
+	s.derivedKey = nil
+	if err := s.saveConfigsNoLock(); err != nil {
+		// 写入是原子的，失败时原文件保持不变
+		restore()
+		return fmt.Errorf("重新加密配置失败: %w", err)
+	}
+
+	if err := s.updateSessionKeyNoLock(newKey); err != nil {
+		// 会话更新失败时用原密钥写回配置文件，不把新密钥加密的文件留作备份；
+		// 此时备份尚未重新加密，仍然使用原密钥
+		s.userKey, s.kdf, s.derivedKey = verifiedKey, verifiedKDF, verifiedDerived
+		if rollbackErr := s.writeConfigsNoLock(false); rollbackErr != nil {
+			fmt.Printf("ChangeUserKey: 回滚配置文件失败: %v\n", rollbackErr)
+		}
+		restore()
+		return fmt.Errorf("更新会话失败: %w", err)
+	}
+
+	// 配置文件和会话都已换成新密钥，备份未能重新加密不影响本次更换，只给出警告
+	if kept := s.reencryptBackupsNoLock(backups, &vaultData{Configs: s.configs, Keys: s.keys}); len(kept) > 0 {
+		fmt.Printf("ChangeUserKey: 警告: %d 个备份未能用新密钥重新加密，仍保持原样: %s\n", len(kept), strings.Join(kept, "、"))
+	}
+
+	fmt.Printf("ChangeUserKey: 密钥更换成功，已重新加密 %d 个配置\n", len(s.configs))
+	return nil
+}
+
+// updateSessionKeyNoLock 更新会话中的密钥校验值，会话不存在时忽略
+func (s *Store) updateSessionKeyNoLock(key string) error {
+	sessionData, err := s.readSessionNoLock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := sessionData.setKey(key); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sessionData, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(s.sessionPath, data, 0600)
+}
+
 // GetConfig 获取指定配置
 func (s *Store) GetConfig(id string) (*models.SSHConfig, error) {
 	s.mu.RLock()
@@ -231,6 +561,20 @@ func (s *Store) DeleteConfig(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// 仍被其他配置用作跳板机时拒绝删除，避免破坏它们的连接链
+	var dependents []string
+	for _, config := range s.configs {
+		for _, jump := range config.JumpHosts {
+			if jump.ConfigID == id {
+				dependents = append(dependents, config.Name)
+				break
+			}
+		}
+	}
+	if len(dependents) > 0 {
+		return fmt.Errorf("该配置仍被用作跳板机: %s，请先修改这些配置", strings.Join(dependents, "、"))
+	}
+
 	for i, config := range s.configs {
 		if config.ID == id {
 			s.configs = append(s.configs[:i], s.configs[i+1:]...)
@@ -248,14 +592,29 @@ func (s *Store) GetAllConfigs() []models.SSHConfig {
 	result := make([]models.SSHConfig, len(s.configs))
 	for i, config := range s.configs {
 		configCopy := config
-		if configCopy.Password != "" {
-			configCopy.Password = "******"
+		maskSecrets(&configCopy.Password, &configCopy.KeyPassphrase)
+		if len(configCopy.JumpHosts) > 0 {
+			jumpHosts := make([]models.JumpHost, len(configCopy.JumpHosts))
+			for j, jump := range configCopy.JumpHosts {
+				maskSecrets(&jump.Password, &jump.KeyPassphrase)
+				jumpHosts[j] = jump
+			}
+			configCopy.JumpHosts = jumpHosts
 		}
 		result[i] = configCopy
 	}
 	return result
 }
 
+// maskSecrets 将非空的敏感字段替换为掩码
+func maskSecrets(fields ...*string) {
+	for _, field := range fields {
+		if *field != "" {
+			*field = "******"
+		}
+	}
+}
+
 // ============ 会话管理 ============
 
 // generateToken 生成随机令牌
@@ -267,10 +626,25 @@ func (s *Store) generateToken() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// hashKey 对密钥进行哈希
-func (s *Store) hashKey(key string) string {
-	hash := sha256.Sum256([]byte(key))
-	return hex.EncodeToString(hash[:])
+// GenerateID 生成配置 ID（与前端 generateId 格式一致：时间戳 + 随机串，36 进制）
+func GenerateID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return strconv.FormatInt(time.Now().UnixMilli(), 36) + strconv.FormatUint(binary.BigEndian.Uint64(bytes), 36)
+}
+
+// readSessionNoLock 读取会话文件（内部方法，不加锁）
+func (s *Store) readSessionNoLock() (*SessionData, error) {
+	data, err := os.ReadFile(s.sessionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionData SessionData
+	if err := json.Unmarshal(data, &sessionData); err != nil {
+		return nil, err
+	}
+	return &sessionData, nil
 }
 
 // CreateSession 创建会话
@@ -294,10 +668,13 @@ func (s *Store) CreateSession() error {
 	// 创建会话数据
 	sessionData := SessionData{
 		Token:     token,
-		KeyHash:   s.hashKey(s.userKey),
 		CreatedAt: time.Now(),
 		ExpiresAt: time.Now().Add(24 * time.Hour), // 24小时过期
 	}
+	if err := sessionData.setKey(s.userKey); err != nil {
+		fmt.Printf("CreateSession: 计算密钥校验值失败: %v\n", err)
+		return err
+	}
 
 	// 保存会话到文件
 	data, err := json.MarshalIndent(sessionData, "", "  ")
@@ -465,22 +842,17 @@ func (s *Store) ClearSession() error {
 
 // ValidateKeyWithSession 使用会话验证密钥
 func (s *Store) ValidateKeyWithSession(key string) error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	// 读取会话数据
-	data, err := os.ReadFile(s.sessionPath)
+	sessionData, err := s.readSessionNoLock()
 	if err != nil {
 		return err
 	}
 
-	var sessionData SessionData
-	if err := json.Unmarshal(data, &sessionData); err != nil {
-		return err
-	}
-
-	// 检查密钥哈希是否匹配
-	if s.hashKey(key) != sessionData.KeyHash {
+	// 检查密钥是否与校验值匹配
+	if !sessionData.matchesKey(key) {
 		return errors.New("密钥不匹配")
 	}
 
@@ -489,6 +861,13 @@ func (s *Store) ValidateKeyWithSession(key string) error {
 		return errors.New("会话已过期")
 	}
 
+	// 旧会话验证通过后改用加盐的校验值，不再保存无盐哈希
+	if sessionData.KeyVerifier == "" {
+		if err := s.updateSessionKeyNoLock(key); err != nil {
+			fmt.Printf("ValidateKeyWithSession: 迁移会话校验值失败: %v\n", err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ssh-mdzz/crypto"
+	"ssh-mdzz/models"
+)
+
+func TestMain(m *testing.M) {
+	// 测试中使用低开销的派生参数
+	crypto.DefaultKDFParams = crypto.KDFParams{Algorithm: "argon2id", Time: 1, Memory: 64, Threads: 1}
+	os.Exit(m.Run())
+}
+
+// newTestStore 创建使用临时目录的存储
+func newTestStore(t *testing.T, key string) *Store {
+	t.Helper()
+
+	dir := t.TempDir()
+	return &Store{
+		configs:      []models.SSHConfig{},
+		userKey:      key,
+		filePath:     filepath.Join(dir, "configs.enc"),
+		sessionPath:  filepath.Join(dir, "session.json"),
+		settingsPath: filepath.Join(dir, "settings.json"),
+	}
+}
+
+func testConfigs() []models.SSHConfig {
+	return []models.SSHConfig{
+		{ID: "a", Name: "web", Host: "10.0.0.1", Port: "22", Username: "root", Password: "pw-a"},
+		{ID: "b", Name: "db", Host: "10.0.0.2", Port: "22", Username: "admin", KeyPassphrase: "pp-b"},
+	}
+}
+
+// encodeStoreFile 生成用 key 加密的配置文件内容
+func encodeStoreFile(t *testing.T, configs []models.SSHConfig, key string) []byte {
+	t.Helper()
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, err := crypto.DeriveKey(key, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := encodeVault(&vaultData{Configs: configs}, params, derived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// readStoreVersion 读取配置文件的格式版本，旧版本的配置数组返回 0
+func readStoreVersion(t *testing.T, path string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0
+	}
+	return file.Version
+}
+
+func TestLoadWithWrongKeyLeavesFileUntouched(t *testing.T) {
+	noSecrets := []models.SSHConfig{{ID: "a", Name: "web", Host: "10.0.0.1", Port: "22", Username: "root"}}
+	legacy, _ := json.Marshal(noSecrets)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		session bool // 是否存在以正确密钥创建的旧会话
+		wantErr error
+	}{
+		{"当前版本", encodeStoreFile(t, testConfigs(), "secret"), false, ErrInvalidKey},
+		{"旧版本配置数组，会话校验失败", legacy, true, ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "wrong")
+			if err := os.WriteFile(s.filePath, tt.data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if tt.session {
+				session, _ := json.Marshal(SessionData{KeyHash: crypto.LegacyKeyHash("secret"), ExpiresAt: time.Now().Add(time.Hour)})
+				if err := os.WriteFile(s.sessionPath, session, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := s.LoadConfigs()
+			if err == nil {
+				t.Fatal("错误的密钥应加载失败")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("错误应为 %v，实际为 %v", tt.wantErr, err)
+			}
+
+			data, err := os.ReadFile(s.filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != string(tt.data) {
+				t.Fatal("错误的密钥不应改写配置文件")
+			}
+		})
+	}
+}
+
+func TestMigrationUpgradesOldBackups(t *testing.T) {
+	// 旧版本配置数组中没有密文，没有会话时直接迁移
+	legacy, _ := json.Marshal([]models.SSHConfig{{ID: "a", Name: "web", Host: "10.0.0.1", Port: "22", Username: "root"}})
+	s := newTestStore(t, "secret")
+	if err := os.WriteFile(s.filePath, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(filepath.Dir(s.filePath), s.backupPrefix()+"20250101-120000.000")
+	if err := os.WriteFile(backup, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.LoadConfigs(); err != nil {
+		t.Fatalf("LoadConfigs: %v", err)
+	}
+	if v := readStoreVersion(t, s.filePath); v != storeFileVersion {
+		t.Fatalf("迁移后文件版本为 %d，期望 %d", v, storeFileVersion)
+	}
+	names, err := s.backupNamesNoLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Fatalf("迁移不应把旧格式文件留作备份，实际备份为 %v", names)
+	}
+	if v := readStoreVersion(t, backup); v != storeFileVersion {
+		t.Fatalf("迁移后备份版本为 %d，期望 %d", v, storeFileVersion)
+	}
+}
+
+func TestSessionKeyVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		session SessionData
+		key     string
+		want    bool
+	}{
+		{"旧会话正确的密钥", SessionData{KeyHash: crypto.LegacyKeyHash("secret")}, "secret", true},
+		{"旧会话错误的密钥", SessionData{KeyHash: crypto.LegacyKeyHash("secret")}, "wrong", false},
+		{"没有校验值", SessionData{}, "secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.session.matchesKey(tt.key); got != tt.want {
+				t.Fatalf("matchesKey() = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	var session SessionData
+	session.KeyHash = crypto.LegacyKeyHash("secret")
+	if err := session.setKey("secret"); err != nil {
+		t.Fatal(err)
+	}
+	if session.KeyHash != "" || session.KeyVerifier == "" || session.KeyKDF == nil {
+		t.Fatalf("setKey 后应只保留加盐的校验值: %+v", session)
+	}
+	if !session.matchesKey("secret") || session.matchesKey("wrong") {
+		t.Fatal("加盐的校验值比较结果不正确")
+	}
+}
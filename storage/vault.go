@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ssh-mdzz/models"
+)
+
+// vaultKey 密钥库中保存的私钥，随配置一起加密
+type vaultKey struct {
+	models.VaultKey
+	PrivateKey string `json:"privateKey"` // OpenSSH 格式私钥
+}
+
+// AddVaultKey 将私钥保存到密钥库，相同指纹的私钥只保存一份
+func (s *Store) AddVaultKey(info models.VaultKey, privateKey []byte) (*models.VaultKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userKey == "" {
+		return nil, errors.New("未设置加密密钥")
+	}
+	if len(privateKey) == 0 {
+		return nil, errors.New("私钥内容为空")
+	}
+
+	for _, key := range s.keys {
+		if key.Fingerprint == info.Fingerprint {
+			return nil, fmt.Errorf("密钥已存在: %s", key.Name)
+		}
+	}
+
+	info.ID = GenerateID()
+	info.CreatedAt = time.Now()
+	info.UsedBy = nil
+	if info.Name == "" {
+		info.Name = info.Fingerprint
+	}
+
+	s.keys = append(s.keys, vaultKey{VaultKey: info, PrivateKey: string(privateKey)})
+	if err := s.saveConfigsNoLock(); err != nil {
+		s.keys = s.keys[:len(s.keys)-1]
+		return nil, err
+	}
+
+	fmt.Printf("AddVaultKey: 已保存密钥 %s (%s)\n", info.Name, info.Fingerprint)
+	return &info, nil
+}
+
+// ListVaultKeys 列出密钥库中的私钥及引用它们的配置
+func (s *Store) ListVaultKeys() []models.VaultKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]models.VaultKey, len(s.keys))
+	for i, key := range s.keys {
+		keys[i] = key.VaultKey
+		keys[i].UsedBy = s.vaultKeyUsersNoLock(key.ID)
+	}
+	return keys
+}
+
+// GetVaultKey 获取密钥库中私钥的信息
+func (s *Store) GetVaultKey(id string) (*models.VaultKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.ID == id {
+			info := key.VaultKey
+			info.UsedBy = s.vaultKeyUsersNoLock(id)
+			return &info, nil
+		}
+	}
+	return nil, fmt.Errorf("密钥不存在: %s", id)
+}
+
+// VaultKeyMaterial 读取密钥库中的私钥内容
+func (s *Store) VaultKeyMaterial(id string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.ID == id {
+			return []byte(key.PrivateKey), nil
+		}
+	}
+	return nil, fmt.Errorf("密钥库中不存在该私钥: %s", id)
+}
+
+// DeleteVaultKey 删除密钥库中的私钥，仍被配置引用时拒绝删除
+func (s *Store) DeleteVaultKey(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, key := range s.keys {
+		if key.ID != id {
+			continue
+		}
+		if users := s.vaultKeyUsersNoLock(id); len(users) > 0 {
+			return fmt.Errorf("密钥仍被 %d 个配置使用，请先修改这些配置", len(users))
+		}
+
+		prevKeys := s.keys
+		s.keys = append(append([]vaultKey(nil), s.keys[:i]...), s.keys[i+1:]...)
+		if err := s.saveConfigsNoLock(); err != nil {
+			s.keys = prevKeys
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("密钥不存在: %s", id)
+}
+
+// vaultKeyUsersNoLock 查找直接或通过跳板机引用指定私钥的配置 ID
+func (s *Store) vaultKeyUsersNoLock(id string) []string {
+	var users []string
+	for _, config := range s.configs {
+		uses := config.KeyID == id
+		for _, jump := range config.JumpHosts {
+			if jump.KeyID == id {
+				uses = true
+			}
+		}
+		if uses {
+			users = append(users, config.ID)
+		}
+	}
+	return users
+}